@@ -3,15 +3,20 @@ package cache
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
 
-	"github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/config"
 )
 
+// ErrNotCached is returned by [Cached] for responses that aren't cached.
+var ErrNotCached = errors.New("not cached")
+
 type Cache struct {
 	Data map[string]CacheItem
 }
@@ -24,7 +29,12 @@ type CacheItem struct {
 func LoadCache() (*Cache, error) {
 	cache := &Cache{Data: make(map[string]CacheItem)}
 
-	file, err := os.Open("/Users/mkozjak/.config/blutui/cache")
+	path, err := config.Path("cache")
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
 	if err != nil {
 		if !os.IsNotExist(err) {
 			return nil, err
@@ -37,17 +47,22 @@ func LoadCache() (*Cache, error) {
 	decoder := json.NewDecoder(file)
 	err = decoder.Decode(cache)
 	if err != nil {
-		internal.Log("Error decoding cache file:", err)
+		slog.Warn("decoding cache file, starting over", "err", err)
 	}
 
 	return cache, nil
 }
 
 func saveCache(cache *Cache) error {
-	file, err := os.OpenFile("/Users/mkozjak/.config/blutui/cache", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	path, err := config.Path("cache")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
-			file, err = os.Create("/Users/mkozjak/.config/blutui/cache")
+			file, err = os.Create(path)
 			if err != nil {
 				return err
 			}
@@ -58,14 +73,24 @@ func saveCache(cache *Cache) error {
 	defer file.Close()
 
 	encoder := json.NewEncoder(file)
-	err = encoder.Encode(cache)
-	if err != nil {
-		internal.Log("Error encoding cache to file:", err)
+	return encoder.Encode(cache)
+}
+
+// Cached returns the cached response to a GET request of url, even if it
+// expired, without making a request.
+func Cached(url string, cache *Cache) ([]byte, error) {
+	item, found := cache.Data[url]
+	if !found {
+		return nil, fmt.Errorf("%s: %w", url, ErrNotCached)
 	}
 
-	return nil
+	return item.Response, nil
 }
 
+// FetchAndCache returns the response to a GET request of url, which is cached.
+// If cached is set, a cached response that hasn't expired is returned without
+// a request. If the request fails, e.g. while the player is offline, a cached
+// response is returned even if it expired.
 func FetchAndCache(url string, cache *Cache, cached bool) ([]byte, error) {
 	var body []byte
 
@@ -75,14 +100,19 @@ func FetchAndCache(url string, cache *Cache, cached bool) ([]byte, error) {
 	} else {
 		resp, err := http.Get(url)
 		if err != nil {
-			internal.Log("Error fetching album section list:", err)
+			if found {
+				slog.Debug("using expired cache", "err", err, "url", url)
+				return item.Response, nil
+			}
+
+			slog.Error("fetching album section list", "err", err)
 			return nil, err
 		}
 		defer resp.Body.Close()
 
 		body, err = io.ReadAll(resp.Body)
 		if err != nil {
-			internal.Log("Error reading response body:", err)
+			slog.Error("reading response body", "err", err)
 			return nil, err
 		}
 
@@ -92,7 +122,7 @@ func FetchAndCache(url string, cache *Cache, cached bool) ([]byte, error) {
 		}
 
 		if err = saveCache(cache); err != nil {
-			internal.Log("Error saving data to local cache:", err)
+			slog.Error("saving data to local cache", "err", err)
 			return nil, err
 		}
 	}
@@ -3,17 +3,35 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/mkozjak/blutui/internal"
 	"github.com/mkozjak/blutui/internal/app"
 	"github.com/mkozjak/blutui/internal/bar"
+	"github.com/mkozjak/blutui/internal/cli"
+	"github.com/mkozjak/blutui/internal/config"
+	"github.com/mkozjak/blutui/internal/control"
+	"github.com/mkozjak/blutui/internal/history"
 	"github.com/mkozjak/blutui/internal/keyboard"
+	"github.com/mkozjak/blutui/internal/keymap"
 	"github.com/mkozjak/blutui/internal/library"
+	"github.com/mkozjak/blutui/internal/logging"
+	"github.com/mkozjak/blutui/internal/mpris"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/nowplaying"
 	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/blutui/internal/playlist"
+	"github.com/mkozjak/blutui/internal/radio"
+	"github.com/mkozjak/blutui/internal/reindex"
+	"github.com/mkozjak/blutui/internal/scrobble"
+	"github.com/mkozjak/blutui/internal/theme"
 	"github.com/mkozjak/blutui/spinner"
 	"github.com/mkozjak/tview"
 )
@@ -27,6 +45,13 @@ var bsUrl string = fmt.Sprintf("%s://%s:%s", proto, host, port)
 func main() {
 	// Define the version flag
 	versionFlag := flag.Bool("version", false, "Display app version")
+	displayFlag := flag.Bool("display", false, "Start on the full-screen now playing page")
+	themeFlag := flag.String("theme", "", "Colour theme: "+strings.Join(theme.Names(), ", "))
+	logLevelFlag := flag.String("log-level", "info", "Log level: debug, info, warn or error")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), cli.Usage+"\nFlags:\n")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	if *versionFlag {
@@ -34,65 +59,171 @@ func main() {
 		return
 	}
 
-	// Check TCP connection to host:port before drawing UI
-	address := net.JoinHostPort(host, port)
-	conn, err := net.DialTimeout("tcp", address, 2*time.Second)
+	// Log to a file in the state directory, carrying on without a log if it can't be opened
+	level, err := logging.ParseLevel(*logLevelFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	lf, err := logging.Setup(level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error opening log file, logging is disabled:", err)
+	}
+	defer lf.Close()
+
+	// Log every request made to the player and other services
+	http.DefaultTransport = logging.Transport{Base: http.DefaultTransport}
+
+	// Run a one-shot command instead of the UI if one is given
+	if flag.NArg() > 0 {
+		os.Exit(cli.Run(flag.Args(), bsUrl, os.Stdout, os.Stderr))
+	}
+
+	slog.Info("starting", "version", appVersion, "player", bsUrl)
+
+	// Load key bindings, refusing to start with invalid or conflicting ones
+	kp, err := config.Path(keymap.File)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error connecting to %s: %v\n", address, err)
+		fmt.Fprintln(os.Stderr, "Error locating key bindings:", err)
 		os.Exit(1)
 	}
-	conn.Close()
+
+	keys, err := keymap.Load(kp)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading key bindings:", err)
+		os.Exit(1)
+	}
+
+	// Load the colour theme, which has to be set before any primitive is created
+	tp, err := config.Path(theme.File)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error locating theme:", err)
+		os.Exit(1)
+	}
+
+	theme.Current, err = theme.Load(tp, *themeFlag, os.Getenv("NO_COLOR") != "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading theme:", err)
+		os.Exit(1)
+	}
+
+	// Load volume control settings
+	vp, err := config.Path(player.VolumeFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error locating volume settings:", err)
+		os.Exit(1)
+	}
+
+	vc, err := player.LoadVolumeConfig(vp)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading volume settings:", err)
+		os.Exit(1)
+	}
+
+	// Load the status bar layout
+	lp, err := config.Path(bar.LayoutFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error locating status bar layout:", err)
+		os.Exit(1)
+	}
+
+	layout, err := bar.LoadLayout(lp)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading status bar layout:", err)
+		os.Exit(1)
+	}
+
+	// Load aliases merging variants of artist names
+	ap, err := config.Path(library.AliasFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error locating artist aliases:", err)
+		os.Exit(1)
+	}
+
+	aliases, err := library.LoadAliases(ap)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading artist aliases:", err)
+		os.Exit(1)
+	}
+
+	// Check TCP connection to host:port before drawing UI. If the player can't
+	// be reached, the UI starts offline, showing the cached library
+	address := net.JoinHostPort(host, port)
+	conn, dialErr := net.DialTimeout("tcp", address, 2*time.Second)
+	if dialErr == nil {
+		conn.Close()
+	}
 
 	// Create main app
 	a := app.New()
-	sp := spinner.New(a.Draw)
+	sp := spinner.New(a.Draw).
+		SetCustomStyle([]rune(theme.Current.Spinner)).
+		SetDone(theme.Current.SpinnerDone)
+
+	// Create a notification center, whose messages are shown on the status bar
+	// and, for warnings and errors, on the messages page
+	nc := notify.New()
 
 	// Create Player and start http long-polling Bluesound for updates
 	pUpd := make(chan player.Status)
-	p := player.New(bsUrl, sp, pUpd)
+	p := player.New(bsUrl, sp, nc, pUpd, vc)
 	a.Player = p
 
+	if dialErr != nil {
+		p.SetOffline()
+		nc.Warn("can't reach player at", dialErr, "address", address)
+	}
+
+	// Listening history, which artists can also be sorted by
+	hp, err := config.StatePath(history.File)
+	if err != nil {
+		nc.Error("locating listening history", err)
+	}
+
+	hs := history.NewStore(hp)
+
 	// Create Local Library Page
 	lfc := make(chan library.FetchDone)
-	lib := library.New(bsUrl, "local", a, p, sp)
+	lib := library.New(bsUrl, "local", a, p, sp, nc, hs, aliases, keys)
 	libc := lib.CreateContainer()
 
 	// Start initial fetching of data
 	go lib.FetchData(true, lfc)
 
+	// Whether the library couldn't be loaded, so it's fetched again once
+	// the player is back online
+	var libFailed atomic.Bool
+
 	go func() {
-		for {
-			msg := <-lfc
+		for msg := range lfc {
+			libFailed.Store(msg.Error != nil)
 			if msg.Error != nil {
-				// TODO: should probably use os.Exit(1) here
-				panic("failed fetching initial local data: " + msg.Error.Error())
+				nc.Error("fetching local library", msg.Error)
 			}
 
-			// Draw initial album list for the first artist in the list
-			lib.DrawArtistPane()
-			lib.DrawInitAlbums()
-			a.Draw()
+			lib.ShowFetched(msg)
 		}
 	}()
 
 	// Create Tidal Page
 	tfc := make(chan library.FetchDone)
-	tidal := library.New(bsUrl, "tidal", a, p, sp)
+	tidal := library.New(bsUrl, "tidal", a, p, sp, nc, hs, aliases, keys)
 	tidalc := tidal.CreateContainer()
 
 	// go tidal.FetchData(true, tfc)
 
+	// Whether the Tidal library couldn't be loaded, see libFailed
+	var tidalFailed atomic.Bool
+
 	go func() {
-		for {
-			msg := <-tfc
+		for msg := range tfc {
+			tidalFailed.Store(msg.Error != nil)
 			if msg.Error != nil {
-				// TODO: should probably use os.Exit(1) here
-				panic("failed fetching initial tidal data: " + msg.Error.Error())
+				nc.Error("fetching Tidal library", msg.Error)
 			}
 
-			// Draw initial album list for the first artist in the list
-			tidal.DrawArtistPane()
-			tidal.DrawInitAlbums()
+			tidal.ShowFetched(msg)
 		}
 	}()
 
@@ -102,30 +233,148 @@ func main() {
 	}
 
 	// Create a bottom Bar container along with its components
-	b := bar.New(a, map[string]bar.LibManager{"local": lib, "tidal": tidal}, sp, pUpd)
+	b := bar.New(a, p, map[string]bar.LibManager{"local": lib, "tidal": tidal}, sp, layout, pUpd, nc.Messages())
+
+	// Create Playlists Page
+	pls := playlist.New(bsUrl, a, p, sp, b, nc, keys)
+	plsc := pls.CreateContainer()
+
+	go pls.FetchData()
+
+	// Create Radio Page
+	rd := radio.New(bsUrl, a, p, sp, b, nc, keys)
+	rdc := rd.CreateContainer()
+
+	go rd.FetchData()
+
+	// Create Now Playing Page
+	np := nowplaying.New(a, p, nc)
+	go np.Listen(p.Subscribe())
+
+	// Create Listening History Page and start recording plays
+	hist := history.New(a, hs, nc, keys)
+	histc := hist.CreateContainer()
+
+	// Start scrobbling if any scrobbling service is configured
+	var sc *scrobble.Scrobbler
+
+	scfg, err := scrobble.LoadConfig()
+	if err != nil {
+		nc.Error("loading scrobbling config", err)
+	}
+
+	if bs := scfg.Backends(); len(bs) > 0 {
+		sd, err := config.StateDir()
+		if err != nil {
+			nc.Error("locating scrobble queue", err)
+		}
+
+		sc = scrobble.New(bs, sd, nc)
+		go sc.Listen(p.Subscribe())
+	}
+
+	go history.NewRecorder(hs, nc, func(pl history.Play) {
+		if sc != nil {
+			go sc.Scrobble(pl)
+		}
+
+		if a.CurrentPage() == history.PageName {
+			hist.Refresh()
+		}
+	}).Listen(p.Subscribe())
+
+	// Export the player on the D-Bus session bus for media keys and desktop widgets
+	go func() {
+		mb, err := mpris.Start(p, bsUrl, nc)
+		if err != nil {
+			// there's no session bus without a desktop, e.g. over SSH
+			slog.Info("MPRIS bridge not started", "err", err)
+			return
+		}
+
+		mb.Listen(p.Subscribe())
+	}()
 
 	// Start listening for Player updates
 	go p.PollStatus()
 
+	// Resume what couldn't be done while the player was offline once it's back
+	go func() {
+		offline := !p.Online()
+
+		for s := range p.Subscribe() {
+			if s.State == "offline" {
+				offline = true
+				continue
+			}
+
+			if offline {
+				if libFailed.Load() {
+					go lib.FetchData(true, lfc)
+				}
+
+				if tidalFailed.Load() {
+					go tidal.FetchData(true, tfc)
+				}
+
+				go rd.Retry()
+			}
+
+			offline = false
+		}
+	}()
+
 	a.Pages = tview.NewPages().
 		AddAndSwitchToPage("local", libc, true).
-		AddPage("tidal", tidalc, true, false)
+		AddPage("tidal", tidalc, true, false).
+		AddPage("playlists", plsc, true, false).
+		AddPage("radio", rdc, true, false).
+		AddPage(nowplaying.PageName, np, true, false).
+		AddPage(history.PageName, histc, true, false).
+		AddPage(notify.PageName, notify.NewPage(nc, keys), true, false)
 
 	a.Pages.SetBackgroundColor(tcell.ColorDefault)
 
 	a.Pages.SetChangedFunc(func() {
-		n, _ := a.Pages.GetFrontPage()
+		n := a.CurrentPage()
 		b.SetPageOnStatus(n)
+
+		// Playlists may have been changed by context menu actions
+		if n == "playlists" {
+			go pls.FetchData()
+		}
+
+		if n == history.PageName {
+			go hist.Refresh()
+		}
 	})
 
+	if *displayFlag {
+		a.Pages.SwitchToPage(nowplaying.PageName)
+	}
+
+	// Let other programs drive this instance through the control socket
+	cs := control.New(a, p, map[string]control.Library{"local": lib, "tidal": tidal}, a.Pages, nc)
+
+	if cp, err := config.RuntimePath(control.SocketFile); err != nil {
+		nc.Error("locating control socket", err)
+	} else if err := cs.Listen(cp); err != nil {
+		nc.Warn("control socket not started", err)
+	}
+
+	defer cs.Close()
+
+	// Library rescans on the player are followed by an update of the local library
+	rs := reindex.New(player.NewClient(bsUrl), p, nc, b.ShowProgress, lib.UpdateData)
+
 	// Configure global keybindings
-	gk := keyboard.NewGlobalHandler(a, a.Player, lib, a.Pages, b)
+	gk := keyboard.NewGlobalHandler(a, a.Player, lib, rs, pls, a.Pages, b, nc, keys)
 	a.Application.SetInputCapture(gk.Listen)
 
 	// Configure helpscreen keybindings
 	// Attach helpscreen to the app
-	hk := keyboard.NewHelpHandler(a.Pages)
-	h := internal.CreateHelpScreen(hk.Listen)
+	hk := keyboard.NewHelpHandler(a.Pages, keys)
+	h := internal.CreateHelpScreen(hk.Listen, keys.Help())
 	a.Pages.AddPage("help", h, false, false)
 
 	// Draw root app window
@@ -136,6 +385,8 @@ func main() {
 
 	// Set app root screen
 	if err := a.Application.SetRoot(a.Root, true).EnableMouse(true).Run(); err != nil {
-		panic(err)
+		slog.Error("running the user interface", "err", err)
+		fmt.Fprintln(os.Stderr, "Error running the user interface:", err)
+		os.Exit(1)
 	}
 }
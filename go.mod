@@ -4,6 +4,7 @@ go 1.22.2
 
 require (
 	github.com/gdamore/tcell/v2 v2.7.4
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/mkozjak/tview v0.0.0-20250129151321-8cddd49e165d
 	golang.org/x/text v0.15.0
 )
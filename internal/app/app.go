@@ -27,10 +27,28 @@ type PageViewer interface {
 	CurrentPage() string
 }
 
+// PageSwitcher represents the ability to show a page given its name.
+type PageSwitcher interface {
+	SwitchToPage(name string)
+}
+
+// PopupShower represents the ability to show a single popup, such as a context
+// menu, centered above the current page.
+type PopupShower interface {
+	ShowPopup(p tview.Primitive, width, height int)
+	HidePopup()
+}
+
 type Drawer interface {
 	Draw() *tview.Application
 }
 
+// Updater represents the ability to safely update primitives from
+// goroutines other than the one running the application's event loop.
+type Updater interface {
+	QueueUpdateDraw(f func()) *tview.Application
+}
+
 type Stopper interface {
 	Stop()
 }
@@ -44,8 +62,16 @@ type App struct {
 	HelpScreen  *tview.Modal
 	Player      *player.Player
 	prevFocused string
+
+	// The following fields hold the page shown below a popup and the
+	// primitive that was focused before the popup was shown.
+	popupPage    string
+	popupFocused tview.Primitive
 }
 
+// popupName is the name of the page that holds a popup.
+const popupName = "popup"
+
 func New() *App {
 	return &App{
 		Application: tview.NewApplication(),
@@ -56,11 +82,27 @@ func (a *App) Draw() *tview.Application {
 	return a.Application.Draw()
 }
 
+func (a *App) QueueUpdateDraw(f func()) *tview.Application {
+	return a.Application.QueueUpdateDraw(f)
+}
+
+// CurrentPage returns the name of the currently shown page.
+// If a popup is shown, the name of the page below it is returned.
 func (a *App) CurrentPage() string {
 	n, _ := a.Pages.GetFrontPage()
+	if n == popupName {
+		return a.popupPage
+	}
+
 	return n
 }
 
+// SwitchToPage hides a popup, if any, and shows the page named name.
+func (a *App) SwitchToPage(name string) {
+	a.HidePopup()
+	a.Pages.SwitchToPage(name)
+}
+
 func (a *App) Play(url string) {
 	go a.Player.Play(url)
 }
@@ -71,6 +113,9 @@ func (a *App) PrevFocused() tview.Primitive {
 		fallthrough
 	case "albumpane":
 		return a.Libs[a.CurrentPage()].GetItem(0)
+	case "playlists", "radio", "history":
+		_, p := a.Pages.GetFrontPage()
+		return p
 	}
 
 	return nil
@@ -90,6 +135,41 @@ func (a *App) ShowBarComponent(c tview.Primitive) {
 	a.Root.AddItem(c, 1, 0, true)
 }
 
+// ShowPopup shows p centered above the current page given its width and height,
+// replacing a previously shown popup, and focuses it.
+func (a *App) ShowPopup(p tview.Primitive, width, height int) {
+	if !a.Pages.HasPage(popupName) {
+		a.popupPage = a.CurrentPage()
+		a.popupFocused = a.Application.GetFocus()
+	}
+
+	c := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	a.Pages.AddPage(popupName, c, true, true)
+	a.Application.SetFocus(p)
+}
+
+// HidePopup hides a currently shown popup and gives the focus back to
+// the primitive that had it before the popup was shown.
+func (a *App) HidePopup() {
+	if !a.Pages.HasPage(popupName) {
+		return
+	}
+
+	a.Pages.RemovePage(popupName)
+
+	if a.popupFocused != nil {
+		a.Application.SetFocus(a.popupFocused)
+		a.popupFocused = nil
+	}
+}
+
 func (a *App) Stop() {
 	a.Application.Stop()
 }
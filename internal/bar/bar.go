@@ -1,9 +1,9 @@
 package bar
 
 import (
-	"github.com/gdamore/tcell/v2"
 	"github.com/mkozjak/blutui/internal/app"
 	"github.com/mkozjak/blutui/internal/library"
+	"github.com/mkozjak/blutui/internal/notify"
 	"github.com/mkozjak/blutui/internal/player"
 	"github.com/mkozjak/blutui/spinner"
 	"github.com/mkozjak/tview"
@@ -19,15 +19,26 @@ type appManager interface {
 	app.Focuser
 	app.StatusbarShower
 	app.PageViewer
+	app.PageSwitcher
 	app.Drawer
 }
 
+// playerController is implemented by the player and lets [StatusBar]
+// control playback with the mouse.
+type playerController interface {
+	Playpause()
+	VolumeUp(big bool)
+	VolumeDown(big bool)
+	Name() string
+}
+
 type LibManager interface {
 	library.ArtistFilter
 	library.CPMarkSetter
 }
 
-// A Bar represents a bottom bar that holds containers such as [SearchBar] or [StatusBar].
+// A Bar represents a bottom bar that holds containers such as [SearchBar], [PromptBar]
+// or [StatusBar].
 type Bar struct {
 	// The following fields hold interfaces that are used for communicating with
 	// app, libraries and spinner instances. App is used for focusing-specific tasks,
@@ -38,23 +49,27 @@ type Bar struct {
 	spinner spinner.Container
 
 	status *StatusBar
+	prompt *PromptBar
 	// tview-specific widgets that represent types compatible with flex widget or
 	// app focusing methods that are used to draw these widgets to the screen.
 	statusc *tview.Grid
 	searchc *tview.InputField
+	promptc *tview.InputField
 
 	// Currently shown container, such as "status" or "search".
 	// Exposed via [CurrentContainer].
 	currCont string
 }
 
-// New returns a new [Bar] given its dependencies app, libraries and spinner instances
-// and a read-only channel that delivers player's updates like play, stream, stop etc.
+// New returns a new [Bar] given its dependencies app, player, libraries and spinner
+// instances, the layout of the status bar, a read-only channel that delivers player's updates like play, stream, stop etc.
+// and a read-only channel that delivers notifications, which are shown as toasts.
 //
 // Returned Bar is suitable to be used for getting tview.Primitive that can be sent to
 // tview's components for drawing to the screen. It is also used for switching between
 // [StatusBar] and [SearchBar].
-func New(a appManager, l map[string]LibManager, sp spinner.Container, ch <-chan player.Status) *Bar {
+func New(a appManager, p playerController, l map[string]LibManager, sp spinner.Container,
+	ly *Layout, ch <-chan player.Status, nch <-chan notify.Message) *Bar {
 	bar := &Bar{
 		app:     a,
 		libs:    l,
@@ -66,9 +81,11 @@ func New(a appManager, l map[string]LibManager, sp spinner.Container, ch <-chan
 		CPMarkSetters[k] = v
 	}
 
-	stb := newStatusBar(a, CPMarkSetters, sp)
+	stb := newStatusBar(a, p, CPMarkSetters, sp, ly)
 	stbc := stb.createContainer()
 	go stb.listen(ch)
+	go stb.listenToasts(nch)
+	go stb.tick()
 
 	artistFilters := make(map[string]library.ArtistFilter)
 	for k, v := range l {
@@ -78,9 +95,14 @@ func New(a appManager, l map[string]LibManager, sp spinner.Container, ch <-chan
 	srb := newSearchBar(a, bar, artistFilters)
 	srbc := srb.createContainer()
 
+	prb := newPromptBar(bar)
+	prbc := prb.createContainer()
+
 	bar.status = stb
+	bar.prompt = prb
 	bar.statusc = stbc
 	bar.searchc = srbc
+	bar.promptc = prbc
 	bar.currCont = "status"
 
 	return bar
@@ -110,6 +132,9 @@ func (b *Bar) Show(name string) {
 	case "search":
 		b.app.ShowBarComponent(b.searchc)
 		b.currCont = "search"
+	case "prompt":
+		b.app.ShowBarComponent(b.promptc)
+		b.currCont = "prompt"
 	case "status":
 		b.app.ShowBarComponent(b.statusc)
 		p := b.app.PrevFocused()
@@ -119,14 +144,21 @@ func (b *Bar) Show(name string) {
 	}
 }
 
+// Prompt shows the [PromptBar] labeled with label and prefilled with text, and
+// focuses it. Once the user confirms their input, done is called with it.
+func (b *Bar) Prompt(label, text string, done func(input string)) {
+	b.prompt.prompt(label, text, done)
+	b.Show("prompt")
+	b.app.SetFocus(b.promptc)
+}
+
+// SetPageOnStatus shows the page named name on the status bar.
 func (b *Bar) SetPageOnStatus(name string) {
-	b.status.currentPage.SetText(name)
-
-	if name == "local" {
-		b.status.currentPage.SetTextColor(tcell.ColorWhite).
-			SetBackgroundColor(tcell.ColorCornflowerBlue)
-	} else if name == "tidal" {
-		b.status.currentPage.SetTextColor(tcell.ColorWhite).
-			SetBackgroundColor(tcell.ColorGrey)
-	}
+	b.status.SetCurrentPage(name)
+}
+
+// ShowProgress shows the progress of a background task, such as a library
+// rescan, on the status bar, or clears it if text is empty.
+func (b *Bar) ShowProgress(text string) {
+	b.status.SetProgress(text)
 }
@@ -0,0 +1,214 @@
+package bar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/mkozjak/tview"
+)
+
+// LayoutFile is the name of a file in the config directory that defines
+// segments of the status bar, e.g.
+//
+//	{
+//	  "segments": [
+//	    {"name": "spinner", "width": 3},
+//	    {"name": "state", "template": "{{.State}} {{.Elapsed}}/{{.Length}}", "width": 24},
+//	    {"name": "title", "width": 0, "align": "center", "marquee": true},
+//	    {"name": "device", "template": "{{.Device}}", "width": 14, "align": "right"}
+//	  ]
+//	}
+const LayoutFile = "statusbar.json"
+
+// A Segment is a part of the status bar showing text made by a template
+// executed with a [Model].
+//
+// The following names come with a default template and behaviour:
+//
+//	spinner  the loading indicator, which has no template
+//	volume   scrolling over it changes volume
+//	state    clicking it toggles play/pause
+//	title    the currently playing song, replaced by toasts for a moment
+//	page     the current page, shown in its colour, clicking it cycles libraries
+//
+// Segments with other names only show their template.
+type Segment struct {
+	Name     string `json:"name"`
+	Template string `json:"template,omitempty"`
+
+	// Width in columns, or 0 to share the space left by other segments.
+	Width int `json:"width"`
+
+	// Align is either left, center or right, defaulting to left.
+	Align string `json:"align,omitempty"`
+
+	// Marquee scrolls text that doesn't fit the segment.
+	Marquee bool `json:"marquee,omitempty"`
+}
+
+// Model is the data segment templates are executed with. Text fields are
+// escaped, so they can be combined with style tags, such as "[::b]".
+type Model struct {
+	State   string // playing, paused, stopped, streaming or an error
+	Artist  string
+	Track   string
+	Album   string
+	Title   string // artist and track, or the title of a stream
+	Format  string // stream format, e.g. FLAC
+	Quality string // stream quality, e.g. hd
+	Volume  int
+	Muted   bool
+	Repeat  string // repeat mode glyph of the theme
+	Shuffle string // shuffle mode glyph of the theme
+	Elapsed string // playing position, e.g. 1:05
+	Length  string // track length, empty for streams
+	Device  string // player's name
+	Page    string // currently shown page
+
+	// Progress of a background task, e.g. "indexing library 01:20", if any
+	Progress string
+}
+
+// defaultTemplates holds templates of segments with a name that doesn't
+// set their own.
+var defaultTemplates = map[string]string{
+	"volume": "vol: {{.Volume}}",
+	"state":  "{{join .State .Repeat .Shuffle .Quality .Format}}",
+	"title":  "{{if .Progress}}[::d]{{.Progress}}[::-]  {{end}}{{.Title}}",
+	"page":   "{{.Page}}",
+}
+
+// templateFuncs holds functions available to segment templates.
+var templateFuncs = template.FuncMap{
+	// join joins non-empty strings with a space
+	"join": func(s ...string) string {
+		var parts []string
+		for _, p := range s {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+
+		return strings.Join(parts, " ")
+	},
+}
+
+var aligns = map[string]int{
+	"":       tview.AlignLeft,
+	"left":   tview.AlignLeft,
+	"center": tview.AlignCenter,
+	"right":  tview.AlignRight,
+}
+
+// A Layout holds segments of the status bar in the order they are shown.
+type Layout struct {
+	Segments []Segment `json:"segments"`
+
+	templates []*template.Template
+}
+
+// DefaultLayout returns the layout used if the [LayoutFile] is missing.
+func DefaultLayout() *Layout {
+	l := &Layout{Segments: []Segment{
+		{Name: "spinner", Width: 3},
+		{Name: "volume", Width: 8},
+		{Name: "state", Width: 20},
+		{Name: "title", Align: "center", Marquee: true},
+		{Name: "page", Width: 10, Align: "center"},
+	}}
+
+	if err := l.compile(); err != nil {
+		panic(err)
+	}
+
+	return l
+}
+
+// LoadLayout reads the status bar layout from the [LayoutFile] at path.
+func LoadLayout(path string) (*Layout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return DefaultLayout(), nil
+		}
+
+		return nil, err
+	}
+
+	var l Layout
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := l.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &l, nil
+}
+
+// compile validates segments and parses their templates, trying each with
+// an empty model so that templates referring to unknown fields are rejected.
+func (l *Layout) compile() error {
+	if len(l.Segments) == 0 {
+		return errors.New("no segments")
+	}
+
+	l.templates = make([]*template.Template, len(l.Segments))
+
+	for i, s := range l.Segments {
+		if s.Width < 0 {
+			return fmt.Errorf("segment %d: negative width", i+1)
+		}
+
+		if _, ok := aligns[s.Align]; !ok {
+			return fmt.Errorf("segment %d: unknown alignment %q", i+1, s.Align)
+		}
+
+		if s.Name == "spinner" {
+			if s.Template != "" {
+				return fmt.Errorf("segment %d: spinner has no template", i+1)
+			}
+
+			continue
+		}
+
+		text := s.Template
+		if text == "" {
+			text = defaultTemplates[s.Name]
+		}
+
+		if text == "" {
+			return fmt.Errorf("segment %d: no template", i+1)
+		}
+
+		t, err := template.New(s.Name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return fmt.Errorf("segment %d: %w", i+1, err)
+		}
+
+		if err := t.Execute(&strings.Builder{}, Model{}); err != nil {
+			return fmt.Errorf("segment %d: %w", i+1, err)
+		}
+
+		l.templates[i] = t
+	}
+
+	return nil
+}
+
+// render returns the text of segment i given model m.
+func (l *Layout) render(i int, m Model) string {
+	var b strings.Builder
+
+	if err := l.templates[i].Execute(&b, m); err != nil {
+		return ""
+	}
+
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package bar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLayout(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, LayoutFile)
+
+	l, err := LoadLayout(path)
+	if err != nil || len(l.Segments) != 5 || l.Segments[3].Name != "title" {
+		t.Fatalf("missing file: got %+v, %v", l, err)
+	}
+
+	m := Model{State: "playing", Repeat: "♯", Quality: "hd", Format: "FLAC", Title: "Low - Days Like These",
+		Volume: 40, Page: "local", Elapsed: "1:05", Length: "4:30"}
+
+	want := []string{"", "vol: 40", "playing ♯ hd FLAC", "Low - Days Like These", "local"}
+	for i, w := range want {
+		if i == 0 {
+			continue
+		}
+
+		if got := l.render(i, m); got != w {
+			t.Errorf("default segment %s = %q, want %q", l.Segments[i].Name, got, w)
+		}
+	}
+
+	os.WriteFile(path, []byte(`{"segments": [
+		{"name": "state", "template": "{{.State}} {{.Elapsed}}/{{.Length}}", "width": 16},
+		{"name": "title", "align": "center", "marquee": true},
+		{"name": "device", "template": "[::b]{{.Device}}", "width": 12, "align": "right"}
+	]}`), 0644)
+
+	l, err = LoadLayout(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Device = "Living Room"
+	if got := l.render(0, m); got != "playing 1:05/4:30" {
+		t.Errorf("state = %q", got)
+	}
+
+	if got := l.render(2, m); got != "[::b]Living Room" {
+		t.Errorf("device = %q", got)
+	}
+}
+
+func TestLoadLayoutInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LayoutFile)
+
+	for _, data := range []string{
+		`{"segments": []}`,
+		`{"segments": [{"name": "clock"}]}`,
+		`{"segments": [{"name": "title", "template": "{{.Weather}}"}]}`,
+		`{"segments": [{"name": "title", "template": "{{.Title"}]}`,
+		`{"segments": [{"name": "title", "align": "middle"}]}`,
+		`{"segments": [{"name": "title", "width": -1}]}`,
+		`{"segments": [{"name": "spinner", "template": "{{.State}}"}]}`,
+		`segments`,
+	} {
+		os.WriteFile(path, []byte(data), 0644)
+
+		if _, err := LoadLayout(path); err == nil {
+			t.Errorf("LoadLayout(%s) should fail", data)
+		}
+	}
+}
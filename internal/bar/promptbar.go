@@ -0,0 +1,77 @@
+package bar
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mkozjak/tview"
+)
+
+// A PromptBar is a [Bar] component that asks the user for a single line of input,
+// such as a name of a playlist the current queue should be saved under.
+// It is shown on Bar by [Bar.Prompt] and falls back to [StatusBar] once the user
+// confirms or cancels their input.
+type PromptBar struct {
+	// switcher is used to fall back to the status bar when the input is done.
+	switcher switcher
+
+	// A tview-specific widget that reads the input from the user.
+	container *tview.InputField
+
+	// done is called with the user's input after it has been confirmed.
+	done func(input string)
+}
+
+// newPromptBar returns a new [PromptBar] given its dependency switcher.
+func newPromptBar(s switcher) *PromptBar {
+	return &PromptBar{switcher: s}
+}
+
+// createContainer creates a [PromptBar] container returning a pointer to
+// tview's InputField type, that is directly used by app in order to turn on
+// the prompt bar on [Bar] and focus it.
+func (p *PromptBar) createContainer() *tview.InputField {
+	p.container = tview.NewInputField().
+		SetLabelColor(tcell.ColorDefault).
+		SetFieldBackgroundColor(tcell.ColorDefault).
+		SetFieldTextColor(tcell.ColorDefault).
+		SetAcceptanceFunc(tview.InputFieldMaxLength(80)).
+		SetDoneFunc(p.finish)
+
+	p.container.SetBackgroundColor(tcell.ColorDefault).
+		SetTitleColor(tcell.ColorDefault).
+		SetBorderPadding(0, 0, 1, 1)
+
+	return p.container
+}
+
+// prompt prepares the input field for a new question given its label, such as
+// "save queue as: ", an initial text and a callback receiving the user's input.
+func (p *PromptBar) prompt(label, text string, done func(input string)) {
+	p.container.SetLabel(label).SetText(text)
+	p.done = done
+}
+
+// finish is a callback method that gets called after the user confirms their
+// input pressing the Enter key or cancels it pressing the Escape key.
+// In both cases the input is reset and the Status Bar is shown again, but
+// only a confirmed, non-empty input is handed over to the done callback.
+//
+// This method is used by tview.InputField.SetDoneFunc method in [createContainer].
+func (p *PromptBar) finish(key tcell.Key) {
+	input := p.container.GetText()
+	done := p.done
+
+	switch key {
+	case tcell.KeyEnter:
+		p.container.SetText("")
+		p.done = nil
+		p.switcher.Show("status")
+
+		if done != nil && input != "" {
+			done(input)
+		}
+	case tcell.KeyEscape:
+		p.container.SetText("")
+		p.done = nil
+		p.switcher.Show("status")
+	}
+}
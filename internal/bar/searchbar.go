@@ -1,11 +1,8 @@
 package bar
 
 import (
-	"slices"
-	"strings"
-
 	"github.com/gdamore/tcell/v2"
-	"github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/fuzzy"
 	"github.com/mkozjak/blutui/internal/library"
 	"github.com/mkozjak/tview"
 )
@@ -56,13 +53,11 @@ func (s *SearchBar) createContainer() *tview.InputField {
 
 // done is a callback method that gets called after the user confirms their
 // search query pressing one of the Enter or Escape keys on the keyboard.
-// In case when Enter is pressed, the program fetches user's input and also
-// prepares tokens that are generated by splitting currently iterated artist name by
-// whitespace (" "). These tokens, along with the query, are then each sent as
-// input to [internal.JWSimilarity], that is the Jaro-Winkler Distance metric implementation.
-// A result that has a better JW score is then chosen for that artist.
+// In case when Enter is pressed, the program fetches user's input and ranks all
+// artists against it using [fuzzy.Rank], which accepts prefix, substring,
+// subsequence and typo-tolerant matches and sorts them from the best one.
 // A method then calls [library.FilterArtistPane], which redraws Artist Pane with
-// search results, or matched artists.
+// search results, or matched artists, in ranked order.
 // In case when Escape is pressed, this method just resets the Search Bar input and
 // shows the Status Bar component.
 //
@@ -74,21 +69,8 @@ func (s *SearchBar) done(key tcell.Key) {
 		query := s.container.GetText()
 		var m []string
 
-		for i := 0; i < len(a); i++ {
-			var scores []float64
-
-			for _, token := range strings.Split(a[i], " ") {
-				scores = append(scores, internal.JWSimilarity(query, token))
-			}
-
-			score := slices.Max(scores)
-			if score == 1 {
-				// Exact match
-				m = []string{a[i]}
-				break
-			} else if score > 0.75 {
-				m = append(m, a[i])
-			}
+		for _, r := range fuzzy.Rank(query, a) {
+			m = append(m, r.Target)
 		}
 
 		if len(m) > 0 {
@@ -1,11 +1,15 @@
 package bar
 
 import (
-	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
+	internal "github.com/mkozjak/blutui/internal"
 	"github.com/mkozjak/blutui/internal/library"
+	"github.com/mkozjak/blutui/internal/notify"
 	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/blutui/internal/theme"
 	"github.com/mkozjak/blutui/spinner"
 	"github.com/mkozjak/tview"
 )
@@ -13,37 +17,85 @@ import (
 // A StatusBar is a [Bar] component that provides important player information
 // such as network activity (net i/o), current volume, state of playback,
 // artist and song names and currently shown page, such as library.
+// What is shown and where is defined by its [Layout].
 // StatusBar is permanently shown on Bar, meaning, all other components fall back to it
 // after they are done with their work.
 type StatusBar struct {
-	// A tview-specific widget that holds all status bar information spread across
+	// A tview-specific widget that holds all status bar segments spread across
 	// the grid view.
 	container *tview.Grid
 
 	// The following fields hold interfaces that are used for communicating with
-	// app, library and spinner instances.
+	// app, player, library and spinner instances.
 	app     appManager
+	player  playerController
 	libs    map[string]library.CPMarkSetter
 	spinner spinner.Container
 
-	// The following fields are tview-specific widgets responsible for holding player
-	// information like currently set volume level, player's current playback state,
-	// a currently playing song (if any) and a currently shown app page.
-	volume       *tview.Table
-	playerStatus *tview.TextView
-	nowPlaying   *tview.TextView
-	currentPage  *tview.TextView
+	layout *Layout
+
+	// Text views of segments, nil for the spinner, in the order of layout's segments.
+	views []*tview.TextView
+
+	// The following fields keep the latest player status and the time it was
+	// received at, for interpolating the playing position, and a toast, a
+	// notification that temporarily replaces the title segment.
+	mu             sync.Mutex
+	status         player.Status
+	updated        time.Time
+	page           string
+	progress       string
+	device         string
+	fetchingDevice bool
+	toast          notify.Message
+	toastTimer     *time.Timer
+
+	// Texts segments currently show, whether they scroll and their marquee
+	// positions, per segment.
+	texts     []string
+	scrolling []bool
+	offsets   []int
 }
 
-// newStatusBar returns a new [StatusBar] given its dependencies app, library and
-// spinner instances.
-// StatusBar is then used for the creation of its child containers for volume,
-// player status, currently played song and currently shown app page.
-func newStatusBar(a appManager, l map[string]library.CPMarkSetter, sp spinner.Container) *StatusBar {
+// toastDuration defines for how long a toast is shown on the status bar.
+// Warnings and errors are shown twice as long.
+const toastDuration = 3 * time.Second
+
+// marqueeInterval defines how often marquee segments scroll by a column, which
+// is also how often the playing position is updated.
+const marqueeInterval = 300 * time.Millisecond
+
+// marqueeGap separates the end of a scrolling text from its beginning.
+const marqueeGap = "   "
+
+// libraryPages holds names of library pages in the order they are cycled
+// by clicking the page label.
+var libraryPages = []string{"local", "tidal"}
+
+// states maps player states to the way they're shown.
+var states = map[string]string{
+	"play":    "playing",
+	"stream":  "streaming",
+	"stop":    "stopped",
+	"pause":   "paused",
+	"offline": "offline",
+	"ctrlerr": "player control error",
+}
+
+// newStatusBar returns a new [StatusBar] given its dependencies app, player, library
+// and spinner instances and the layout of its segments.
+// StatusBar is then used for the creation of its child containers for each segment.
+func newStatusBar(a appManager, p playerController, l map[string]library.CPMarkSetter,
+	sp spinner.Container, ly *Layout) *StatusBar {
 	return &StatusBar{
-		app:     a,
-		libs:    l,
-		spinner: sp,
+		app:       a,
+		player:    p,
+		libs:      l,
+		spinner:   sp,
+		layout:    ly,
+		texts:     make([]string, len(ly.Segments)),
+		scrolling: make([]bool, len(ly.Segments)),
+		offsets:   make([]int, len(ly.Segments)),
 	}
 }
 
@@ -51,36 +103,33 @@ func newStatusBar(a appManager, l map[string]library.CPMarkSetter, sp spinner.Co
 // tview's Grid type, that is directly used by app in order to turn on
 // the status bar on [Bar] to show important player status messages.
 func (sb *StatusBar) createContainer() *tview.Grid {
-	sb.volume = tview.NewTable().
-		SetFixed(1, 2).SetSelectable(false, false)
-
-	sb.volume.Box.SetBackgroundColor(tcell.ColorDefault)
+	sb.container = tview.NewGrid()
+	sb.views = make([]*tview.TextView, len(sb.layout.Segments))
 
-	sb.volume.SetCell(0, 0, tview.NewTableCell("").SetTextColor(tcell.ColorDefault)).
-		SetCell(0, 1, tview.NewTableCell("").SetTextColor(tcell.ColorDefault))
+	var columns []int
 
-	sb.playerStatus = tview.NewTextView()
-	sb.playerStatus.SetTextColor(tcell.ColorDefault).SetBackgroundColor(tcell.ColorDefault)
+	for i, s := range sb.layout.Segments {
+		columns = append(columns, s.Width)
 
-	sb.nowPlaying = tview.NewTextView()
-	sb.nowPlaying.SetTextColor(tcell.ColorDefault).SetBackgroundColor(tcell.ColorDefault)
+		if s.Name == "spinner" {
+			sb.container.AddItem(sb.spinner.Container(), 0, i, 1, 1, 1, 1, false)
+			continue
+		}
 
-	sb.currentPage = tview.NewTextView()
-	sb.currentPage.SetChangedFunc(func() {
-		sb.app.Draw()
-	})
+		v := tview.NewTextView().
+			SetWrap(false).
+			SetDynamicColors(true).
+			SetTextAlign(aligns[s.Align])
 
-	sb.currentPage.SetTextColor(tcell.ColorDefault).SetBackgroundColor(tcell.ColorDefault)
+		v.SetTextColor(theme.Current.Text).SetBackgroundColor(tcell.ColorDefault)
 
-	sb.container = tview.NewGrid().
-		AddItem(sb.spinner.Container(), 0, 0, 1, 1, 1, 1, false).
-		AddItem(sb.volume, 0, 1, 1, 1, 1, 8, false).
-		AddItem(sb.playerStatus, 0, 2, 1, 1, 1, 20, false).
-		AddItem(sb.nowPlaying, 0, 3, 1, 1, 1, 50, false).
-		AddItem(sb.currentPage, 0, 4, 1, 1, 1, 10, false).
-		SetColumns(3, 8, 20, 0, 10)
+		sb.views[i] = v
+		sb.container.AddItem(v, 0, i, 1, 1, 1, 1, false)
+	}
 
+	sb.container.SetColumns(columns...)
 	sb.container.SetBackgroundColor(tcell.ColorDefault).SetBorder(false).SetBorderPadding(0, 0, 1, 1)
+	sb.container.SetMouseCapture(sb.mouseHandler)
 
 	return sb.container
 }
@@ -92,94 +141,321 @@ func (sb *StatusBar) createContainer() *tview.Grid {
 // management errors.
 func (sb *StatusBar) listen(ch <-chan player.Status) {
 	for s := range ch {
-		var cpTitle string
-		var cpFormat string
-		var cpQuality string
 		currPage := sb.app.CurrentPage()
 
+		// Pages such as playlists don't hold a library to mark tracks on
+		cpm, isLib := sb.libs[currPage]
+
 		switch s.State {
 		case "play":
-			s.State = "playing"
-			cpTitle = s.Artist + " - " + s.Track
-			cpFormat = s.Format
-			cpQuality = s.Quality
-
-			sb.libs[currPage].MarkCpArtist(s.Artist)
-			sb.libs[currPage].MarkCpTrack(s.Track, s.Artist, s.Album)
-			sb.libs[currPage].SetCpTrackName(s.Track)
-			sb.libs[currPage].SetCpAlbumName(s.Album)
-		case "stream":
-			s.State = "streaming"
-			cpTitle = s.Title2
-			cpFormat = s.Format
-			cpQuality = s.Quality
+			if isLib {
+				cpm.MarkCpArtist(s.Artist, s.Album)
+				cpm.MarkCpTrack(s.Track, s.Artist, s.Album, s.File)
+				cpm.SetCpTrackName(s.Track)
+				cpm.SetCpAlbumName(s.Album)
+			}
 		case "stop":
-			s.State = "stopped"
-			cpTitle = ""
-			cpFormat = ""
-			cpQuality = ""
-			sb.libs[currPage].MarkCpArtist("")
-			sb.libs[currPage].SetCpTrackName("")
-		case "pause":
-			s.State = "paused"
-
-			if s.Artist == "" && s.Track == "" {
-				// streaming, set title to Title3 from /Status
-				cpTitle = s.Title3
-			} else {
-				cpTitle = s.Artist + " - " + s.Track
+			if isLib {
+				cpm.MarkCpArtist("", "")
+				cpm.SetCpTrackName("")
 			}
+		}
+
+		sb.mu.Lock()
+		// the same status may come again, e.g. with an optimistic volume level,
+		// which must not move the playing position back
+		if s.ETag == "" || s.ETag != sb.status.ETag {
+			sb.updated = time.Now()
+		}
+
+		// marquee segments start over with another song
+		if s.Song != sb.status.Song || s.Title2 != sb.status.Title2 {
+			clear(sb.offsets)
+		}
+
+		sb.status = s
+		sb.page = currPage
+
+		fetchDevice := sb.device == "" && !sb.fetchingDevice && s.State != "offline"
+		if fetchDevice {
+			sb.fetchingDevice = true
+		}
+		sb.mu.Unlock()
 
-			cpFormat = s.Format
-			cpQuality = s.Quality
-		case "neterr":
-			s.State = "network error"
-			cpTitle = ""
-			cpFormat = ""
-			cpQuality = ""
-		case "ctrlerr":
-			s.State = "player control error"
-			cpTitle = ""
-			cpFormat = ""
-			cpQuality = ""
-		}
-
-		format := ""
-		if cpQuality != "" || cpFormat != "" {
-			format = cpQuality + " " + cpFormat
-		}
-
-		var repeat string
-		switch s.Repeat {
-		case 0:
-			repeat = " ♯ "
-		case 1:
-			repeat = " ∞ "
-		default:
-			repeat = " "
-		}
-
-		sb.volume.SetCell(0, 0, tview.NewTableCell("vol:").SetTextColor(tcell.ColorDefault))
-		sb.volume.SetCell(0, 1, tview.NewTableCell(strconv.Itoa(s.Volume)).SetTextColor(tcell.ColorDefault))
-		sb.playerStatus.SetText(s.State + repeat + format).SetTextAlign(tview.AlignLeft)
-		sb.nowPlaying.SetText(cpTitle).SetTextAlign(tview.AlignCenter)
-		sb.currentPage.SetText(currPage).SetTextAlign(tview.AlignCenter).SetTextColor(tcell.ColorBlack).
-			SetBackgroundColor(tcell.ColorCornflowerBlue)
-
-		if currPage == "local" {
-			sb.currentPage.SetTextColor(tcell.ColorWhite).
-				SetBackgroundColor(tcell.ColorCornflowerBlue)
-		} else if currPage == "tidal" {
-			sb.currentPage.SetTextColor(tcell.ColorWhite).
-				SetBackgroundColor(tcell.ColorGrey)
+		if fetchDevice {
+			go sb.fetchDevice()
 		}
 
+		sb.render(false)
+		sb.app.Draw()
+	}
+}
+
+// fetchDevice fetches the name of the player for the Device field of [Model].
+func (sb *StatusBar) fetchDevice() {
+	n := sb.player.Name()
+
+	sb.mu.Lock()
+	sb.device = n
+	sb.fetchingDevice = false
+	sb.mu.Unlock()
+
+	if n != "" {
+		sb.render(false)
 		sb.app.Draw()
 	}
 }
 
-// SetCurrentPage updates the label showing currently open application page
-// such as Library or Help screen given its input page name.
+// tick moves marquee segments and the playing position on until the app quits.
+func (sb *StatusBar) tick() {
+	t := time.NewTicker(marqueeInterval)
+	defer t.Stop()
+
+	for range t.C {
+		if sb.render(true) {
+			sb.app.Draw()
+		}
+	}
+}
+
+// model returns the [Model] of the latest player status.
+func (sb *StatusBar) model() Model {
+	s := sb.status
+
+	m := Model{
+		State:    states[s.State],
+		Volume:   s.Volume,
+		Muted:    s.Mute == 1,
+		Repeat:   tview.Escape(theme.Current.RepeatGlyph(s.Repeat)),
+		Shuffle:  tview.Escape(theme.Current.ShuffleGlyph(s.Shuffle == 1)),
+		Device:   tview.Escape(sb.device),
+		Page:     sb.page,
+		Progress: tview.Escape(sb.progress),
+	}
+
+	switch s.State {
+	case "offline":
+		// stands out, as commands aren't available
+		m.State = theme.Current.Tag(theme.Current.Warning) + "[::b]" + m.State + "[-:-:-]"
+	case "play", "pause":
+		m.Artist, m.Track, m.Album = s.Artist, s.Track, s.Album
+		m.Title = s.Artist + " - " + s.Track
+
+		if s.Artist == "" && s.Track == "" {
+			// paused stream, its title is in Title3
+			m.Title = s.Title3
+		}
+	case "stream":
+		m.Artist, m.Track = s.Title3, s.Title2
+		m.Title = s.Title2
+	}
+
+	if m.Title != "" {
+		m.Format, m.Quality = s.Format, s.Quality
+
+		secs := s.Secs
+		if s.State == "play" || s.State == "stream" {
+			secs += int(time.Since(sb.updated).Seconds())
+		}
+
+		if s.TrackLen > 0 {
+			secs = min(secs, s.TrackLen)
+			m.Length = internal.FormatDuration(s.TrackLen)
+		}
+
+		m.Elapsed = internal.FormatDuration(secs)
+	}
+
+	for _, f := range []*string{&m.Artist, &m.Track, &m.Album, &m.Title, &m.Format, &m.Quality} {
+		*f = tview.Escape(*f)
+	}
+
+	return m
+}
+
+// render updates texts of all segments. If step is set, marquee segments
+// whose text doesn't fit scroll by a column. It returns whether anything
+// changed on screen.
+func (sb *StatusBar) render(step bool) bool {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	m := sb.model()
+	changed := false
+
+	for i, s := range sb.layout.Segments {
+		v := sb.views[i]
+		if v == nil {
+			continue
+		}
+
+		text := sb.layout.render(i, m)
+		if s.Name == "title" && sb.toast.Text != "" {
+			text = toastText(sb.toast)
+		}
+
+		if s.Name == "page" {
+			fg, bg := theme.Current.PageColors(m.Page)
+			v.SetTextColor(fg).SetBackgroundColor(bg)
+		}
+
+		_, _, width, _ := v.GetInnerRect()
+		overflows := s.Marquee && width > 0 && tview.TaggedStringWidth(text) > width
+		same := text == sb.texts[i] && overflows == sb.scrolling[i]
+
+		switch {
+		case same && !(overflows && step):
+			continue
+		case same:
+			sb.offsets[i]++
+		case !overflows:
+			sb.offsets[i] = 0
+		}
+
+		// texts that keep changing, e.g. with the playing position, keep scrolling
+		sb.offsets[i] %= tview.TaggedStringWidth(text) + len(marqueeGap)
+
+		sb.texts[i] = text
+		sb.scrolling[i] = overflows
+		changed = true
+
+		if overflows {
+			// the text repeats after a gap, so that scrolling wraps around
+			v.SetText(text+marqueeGap+text).SetTextAlign(tview.AlignLeft).ScrollTo(0, sb.offsets[i])
+		} else {
+			v.SetText(text).SetTextAlign(aligns[s.Align]).ScrollToBeginning()
+		}
+	}
+
+	return changed
+}
+
+// listenToasts shows every notification received on the given read-only
+// channel in place of the currently playing song until [toastDuration] passes
+// or the next one arrives.
+func (sb *StatusBar) listenToasts(ch <-chan notify.Message) {
+	for msg := range ch {
+		d := toastDuration
+		if msg.Level > notify.Info {
+			d *= 2
+		}
+
+		sb.mu.Lock()
+		if sb.toastTimer != nil {
+			sb.toastTimer.Stop()
+		}
+
+		sb.toast = msg
+		sb.toastTimer = time.AfterFunc(d, sb.clearToast)
+		sb.mu.Unlock()
+
+		sb.render(false)
+		sb.app.Draw()
+	}
+}
+
+// clearToast restores the currently playing song after a toast expires.
+func (sb *StatusBar) clearToast() {
+	sb.mu.Lock()
+	sb.toastTimer = nil
+	sb.toast = notify.Message{}
+	sb.mu.Unlock()
+
+	sb.render(false)
+	sb.app.Draw()
+}
+
+// toastText returns the text of a toast showing notification m, which is
+// labelled with its level unless it's informational.
+func toastText(m notify.Message) string {
+	if m.Level == notify.Info {
+		return "[::b]" + tview.Escape(m.Text)
+	}
+
+	return notify.LevelTag(m.Level) + "[::b]" + m.Level.String() + ": " + tview.Escape(m.Text)
+}
+
+// mouseHandler makes status bar segments clickable. Clicking the state segment
+// toggles play/pause, scrolling over the volume segment changes volume,
+// clicking the page segment cycles library pages and clicking a warning or
+// an error opens the messages page. Mouse events never focus
+// the status bar.
+func (sb *StatusBar) mouseHandler(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	x, y := event.Position()
+	if !sb.container.InRect(x, y) {
+		return action, event
+	}
+
+	var name string
+	for i, v := range sb.views {
+		if v != nil && v.InRect(x, y) {
+			name = sb.layout.Segments[i].Name
+		}
+	}
+
+	switch {
+	case action == tview.MouseLeftClick && name == "state":
+		go sb.player.Playpause()
+	case action == tview.MouseScrollUp && name == "volume":
+		go sb.player.VolumeUp(false)
+	case action == tview.MouseScrollDown && name == "volume":
+		go sb.player.VolumeDown(false)
+	case action == tview.MouseLeftClick && name == "page":
+		sb.app.SwitchToPage(nextLibraryPage(sb.app.CurrentPage(), sb.libs))
+	case action == tview.MouseLeftClick && name == "title":
+		sb.mu.Lock()
+		l := sb.toast.Level
+		sb.mu.Unlock()
+
+		if l > notify.Info {
+			sb.app.SwitchToPage(notify.PageName)
+		}
+	}
+
+	return tview.MouseConsumed, nil
+}
+
+// nextLibraryPage returns the library page that follows page in [libraryPages],
+// skipping those not in libs. The first one is returned for other pages.
+func nextLibraryPage(page string, libs map[string]library.CPMarkSetter) string {
+	var pages []string
+	for _, p := range libraryPages {
+		if _, ok := libs[p]; ok {
+			pages = append(pages, p)
+		}
+	}
+
+	if len(pages) == 0 {
+		return page
+	}
+
+	for i, p := range pages {
+		if p == page {
+			return pages[(i+1)%len(pages)]
+		}
+	}
+
+	return pages[0]
+}
+
+// SetCurrentPage updates the page segment showing currently open application
+// page such as Library or Help screen given its input page name.
 func (sb *StatusBar) SetCurrentPage(name string) {
-	sb.currentPage.SetText(name)
+	sb.mu.Lock()
+	sb.page = name
+	sb.mu.Unlock()
+
+	sb.render(false)
+}
+
+// SetProgress updates the progress of a background task shown by the Progress
+// field of [Model].
+func (sb *StatusBar) SetProgress(text string) {
+	sb.mu.Lock()
+	sb.progress = text
+	sb.mu.Unlock()
+
+	if sb.render(false) {
+		sb.app.Draw()
+	}
 }
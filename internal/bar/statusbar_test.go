@@ -0,0 +1,30 @@
+package bar
+
+import (
+	"testing"
+
+	"github.com/mkozjak/blutui/internal/library"
+)
+
+func TestNextLibraryPage(t *testing.T) {
+	both := map[string]library.CPMarkSetter{"local": nil, "tidal": nil}
+	local := map[string]library.CPMarkSetter{"local": nil}
+
+	tests := []struct {
+		page string
+		libs map[string]library.CPMarkSetter
+		want string
+	}{
+		{"local", both, "tidal"},
+		{"tidal", both, "local"},
+		{"radio", both, "local"},
+		{"local", local, "local"},
+		{"history", nil, "history"},
+	}
+
+	for _, tt := range tests {
+		if got := nextLibraryPage(tt.page, tt.libs); got != tt.want {
+			t.Errorf("nextLibraryPage(%q, %v) = %q, want %q", tt.page, tt.libs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// Package cli implements one-shot subcommands that control the player
+// without starting the terminal UI, e.g. from shell scripts or window
+// manager keybindings.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/config"
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+// Exit codes returned by [Run].
+const (
+	ExitOK    = 0
+	ExitError = 1 // the player couldn't be reached or rejected the command
+	ExitUsage = 2 // the command line is invalid
+)
+
+// Usage describes available subcommands.
+const Usage = `Usage: blutui [flags] [command]
+
+Without a command, blutui starts its terminal user interface.
+
+Commands:
+  status [--json]         print player status
+  play                    start or resume playback
+  pause                   pause playback
+  toggle                  toggle between playing and pausing
+  stop                    stop playback
+  next                    play the next track
+  prev                    play the previous track
+  volume [N|+N|-N]        print volume, set it to N or change it by N
+  mute                    toggle mute
+  repeat [all|one|off]    print or set repeat mode
+  queue list [--json]     print the play queue
+  rescan                  rescan the library on the player
+`
+
+// repeatModes are names of player's repeat modes, indexed by mode.
+var repeatModes = []string{"all", "one", "off"}
+
+var errUsage = errors.New("invalid usage")
+
+// Status is the machine-readable player status printed by "status --json".
+type Status struct {
+	State    string `json:"state"`
+	Artist   string `json:"artist"`
+	Album    string `json:"album"`
+	Track    string `json:"track"`
+	Service  string `json:"service"`
+	Format   string `json:"format"`
+	Quality  string `json:"quality"`
+	Position int    `json:"position"`
+	Length   int    `json:"length"`
+	Volume   int    `json:"volume"`
+	Muted    bool   `json:"muted"`
+	Repeat   string `json:"repeat"`
+	Shuffle  bool   `json:"shuffle"`
+	Song     int    `json:"song"`
+}
+
+// StatusOf converts player's status into its machine-readable form.
+func StatusOf(s player.Status) Status {
+	st := Status{
+		State:    s.State,
+		Artist:   s.Artist,
+		Album:    s.Album,
+		Track:    s.Track,
+		Service:  s.Service,
+		Format:   s.Format,
+		Quality:  s.Quality,
+		Position: s.Secs,
+		Length:   s.TrackLen,
+		Volume:   s.Volume,
+		Muted:    s.Mute == 1,
+		Repeat:   repeatName(s.Repeat),
+		Shuffle:  s.Shuffle == 1,
+		Song:     s.Song,
+	}
+
+	if s.State == "stream" {
+		// radio stations put their metadata into title lines
+		st.Track, st.Artist = s.Title2, s.Title3
+	}
+
+	return st
+}
+
+// QueueTrack is a machine-readable track printed by "queue list --json".
+type QueueTrack struct {
+	ID      int    `json:"id"`
+	Artist  string `json:"artist"`
+	Album   string `json:"album"`
+	Title   string `json:"title"`
+	Current bool   `json:"current"`
+}
+
+// Run runs the command given in args, without the program name, against
+// the player at api. It returns the exit code the program should exit with.
+func Run(args []string, api string, stdout, stderr io.Writer) int {
+	c := &command{client: player.NewClient(api), out: stdout}
+
+	err := c.run(args)
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(stderr, Usage)
+		return ExitUsage
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, "blutui:", err)
+		return ExitError
+	}
+
+	return ExitOK
+}
+
+type command struct {
+	client *player.Client
+	out    io.Writer
+}
+
+func (c *command) run(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	name, args := args[0], args[1:]
+
+	switch name {
+	case "status":
+		return c.status(args)
+	case "play", "pause", "toggle", "stop", "next", "prev":
+		if len(args) != 0 {
+			return errUsage
+		}
+
+		return map[string]func() error{
+			"play":   c.client.Resume,
+			"pause":  c.client.Pause,
+			"toggle": c.client.Playpause,
+			"stop":   c.client.Stop,
+			"next":   c.client.Next,
+			"prev":   c.client.Previous,
+		}[name]()
+	case "volume":
+		return c.volume(args)
+	case "mute":
+		if len(args) != 0 {
+			return errUsage
+		}
+
+		return c.mute()
+	case "repeat":
+		return c.repeat(args)
+	case "rescan":
+		if len(args) != 0 {
+			return errUsage
+		}
+
+		return c.client.Reindex()
+	case "queue":
+		if len(args) == 0 || args[0] != "list" {
+			return errUsage
+		}
+
+		return c.queue(args[1:])
+	}
+
+	return errUsage
+}
+
+// parseJSONFlag parses args that may only hold the --json flag.
+func parseJSONFlag(name string, args []string) (bool, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return false, errUsage
+	}
+
+	return *asJSON, nil
+}
+
+func (c *command) status(args []string) error {
+	asJSON, err := parseJSONFlag("status", args)
+	if err != nil {
+		return err
+	}
+
+	s, err := c.client.Status()
+	if err != nil {
+		return err
+	}
+
+	st := StatusOf(s)
+
+	if asJSON {
+		return json.NewEncoder(c.out).Encode(st)
+	}
+
+	fmt.Fprintf(c.out, "state:    %s\n", st.State)
+	fmt.Fprintf(c.out, "artist:   %s\n", st.Artist)
+	fmt.Fprintf(c.out, "album:    %s\n", st.Album)
+	fmt.Fprintf(c.out, "track:    %s\n", st.Track)
+	fmt.Fprintf(c.out, "position: %s / %s\n", internal.FormatDuration(st.Position), internal.FormatDuration(st.Length))
+	fmt.Fprintf(c.out, "volume:   %d%s\n", st.Volume, map[bool]string{true: " (muted)"}[st.Muted])
+	fmt.Fprintf(c.out, "repeat:   %s\n", st.Repeat)
+	fmt.Fprintf(c.out, "shuffle:  %s\n", map[bool]string{true: "on", false: "off"}[st.Shuffle])
+
+	return nil
+}
+
+func (c *command) volume(args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+
+	v, muted, err := c.client.Volume()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		n, err := player.ParseVolume(args[0], v)
+		if err != nil {
+			return errUsage
+		}
+
+		vp, err := config.Path(player.VolumeFile)
+		if err != nil {
+			return err
+		}
+
+		vc, err := player.LoadVolumeConfig(vp)
+		if err != nil {
+			return err
+		}
+
+		c.client.MaxVolume = vc.Max
+		v = min(max(n, 0), vc.Max)
+
+		if err := c.client.SetVolume(v); err != nil {
+			return err
+		}
+	}
+
+	if muted {
+		fmt.Fprintln(c.out, v, "muted")
+	} else {
+		fmt.Fprintln(c.out, v)
+	}
+
+	return nil
+}
+
+func (c *command) mute() error {
+	_, muted, err := c.client.Volume()
+	if err != nil {
+		return err
+	}
+
+	if err := c.client.SetMute(!muted); err != nil {
+		return err
+	}
+
+	if muted {
+		fmt.Fprintln(c.out, "unmuted")
+	} else {
+		fmt.Fprintln(c.out, "muted")
+	}
+
+	return nil
+}
+
+func (c *command) repeat(args []string) error {
+	switch len(args) {
+	case 0:
+		m, err := c.client.RepeatMode()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(c.out, repeatName(m))
+		return nil
+	case 1:
+		if m, ok := ParseRepeatMode(args[0]); ok {
+			return c.client.SetRepeatMode(m)
+		}
+	}
+
+	return errUsage
+}
+
+func (c *command) queue(args []string) error {
+	asJSON, err := parseJSONFlag("queue list", args)
+	if err != nil {
+		return err
+	}
+
+	s, err := c.client.Status()
+	if err != nil {
+		return err
+	}
+
+	tracks, err := c.client.Queue(0, -1)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		list := []QueueTrack{}
+		for _, t := range tracks {
+			list = append(list, QueueTrack{ID: t.ID, Artist: t.Artist, Album: t.Album, Title: t.Title,
+				Current: t.ID == s.Song})
+		}
+
+		return json.NewEncoder(c.out).Encode(list)
+	}
+
+	// tab separated, with the current track marked, e.g. for cut or awk
+	for _, t := range tracks {
+		mark := " "
+		if t.ID == s.Song {
+			mark = "*"
+		}
+
+		fmt.Fprintf(c.out, "%s\t%d\t%s\t%s\t%s\n", mark, t.ID, t.Artist, t.Title, t.Album)
+	}
+
+	return nil
+}
+
+// ParseRepeatMode returns the repeat mode named all, one or off.
+func ParseRepeatMode(name string) (int, bool) {
+	for m, n := range repeatModes {
+		if n == name {
+			return m, true
+		}
+	}
+
+	return -1, false
+}
+
+func repeatName(mode int) string {
+	if mode >= 0 && mode < len(repeatModes) {
+		return repeatModes[mode]
+	}
+
+	return strconv.Itoa(mode)
+}
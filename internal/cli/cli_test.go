@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+// standIn serves a player with fixed status and records commands.
+func standIn(t *testing.T, commands *[]string) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RequestURI() {
+		case "/Status":
+			w.Write([]byte(`<status etag="1"><state>play</state><artist>Low</artist><album>Hey What</album>` +
+				`<name>White Horses</name><secs>30</secs><totlen>300</totlen><volume>40</volume>` +
+				`<repeat>1</repeat><song>1</song></status>`))
+		case "/Volume":
+			w.Write([]byte(`<volume mute="0">40</volume>`))
+		case "/Playlist?start=0":
+			w.Write([]byte(`<playlist><song id="0"><title>Days Like These</title><art>Low</art></song>` +
+				`<song id="1"><title>White Horses</title><art>Low</art></song></playlist>`))
+		default:
+			*commands = append(*commands, r.URL.RequestURI())
+			w.Write([]byte(`<ok/>`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var commands []string
+	api := standIn(t, &commands)
+
+	tests := []struct {
+		args    []string
+		code    int
+		out     string
+		command string
+	}{
+		{[]string{"toggle"}, ExitOK, "", "/Pause?toggle=1"},
+		{[]string{"prev"}, ExitOK, "", "/Back"},
+		{[]string{"volume"}, ExitOK, "40\n", ""},
+		{[]string{"volume", "+5"}, ExitOK, "45\n", "/Volume?level=45"},
+		{[]string{"volume", "-50"}, ExitOK, "0\n", "/Volume?level=0"},
+		{[]string{"volume", "70"}, ExitOK, "70\n", "/Volume?level=70"},
+		{[]string{"mute"}, ExitOK, "muted\n", "/Volume?mute=1"},
+		{[]string{"repeat", "off"}, ExitOK, "", "/Repeat?state=2"},
+		{[]string{"rescan"}, ExitOK, "", "/Reindex"},
+		{[]string{"queue", "list"}, ExitOK, " \t0\tLow\tDays Like These\t\n*\t1\tLow\tWhite Horses\t\n", ""},
+		{[]string{"repeat", "sometimes"}, ExitUsage, "", ""},
+		{[]string{"volume", "loud"}, ExitUsage, "", ""},
+		{[]string{"status", "--xml"}, ExitUsage, "", ""},
+		{[]string{"dance"}, ExitUsage, "", ""},
+	}
+
+	for _, tt := range tests {
+		commands = nil
+
+		var out, errOut bytes.Buffer
+		code := Run(tt.args, api, &out, &errOut)
+
+		if code != tt.code || out.String() != tt.out {
+			t.Errorf("%v: got %d %q, want %d %q (stderr %q)", tt.args, code, out.String(), tt.code, tt.out, errOut.String())
+		}
+
+		if tt.command != "" && (len(commands) != 1 || commands[0] != tt.command) {
+			t.Errorf("%v: sent %v, want %s", tt.args, commands, tt.command)
+		}
+	}
+}
+
+func TestVolumeCap(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "blutui"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "blutui", player.VolumeFile), []byte(`{"max": 60}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var commands []string
+	api := standIn(t, &commands)
+
+	var out bytes.Buffer
+	if code := Run([]string{"volume", "+50"}, api, &out, &out); code != ExitOK || out.String() != "60\n" {
+		t.Errorf("got %d %q, want 60", code, out.String())
+	}
+
+	if len(commands) != 1 || commands[0] != "/Volume?level=60" {
+		t.Errorf("sent %v, want /Volume?level=60", commands)
+	}
+}
+
+func TestStatusJSON(t *testing.T) {
+	api := standIn(t, new([]string))
+
+	var out bytes.Buffer
+	if code := Run([]string{"status", "--json"}, api, &out, &out); code != ExitOK {
+		t.Fatalf("got exit code %d: %s", code, out.String())
+	}
+
+	var s Status
+	if err := json.Unmarshal(out.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Status{State: "play", Artist: "Low", Album: "Hey What", Track: "White Horses",
+		Position: 30, Length: 300, Volume: 40, Repeat: "one", Song: 1}
+	if s != want {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	api := srv.URL
+	srv.Close()
+
+	var out bytes.Buffer
+	if code := Run([]string{"next"}, api, &out, &out); code != ExitError {
+		t.Errorf("got exit code %d, want %d", code, ExitError)
+	}
+}
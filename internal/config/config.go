@@ -0,0 +1,78 @@
+// Package config locates blutui's configuration, state and runtime directories.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dir returns the directory holding blutui's configuration and cache files,
+// which is $XDG_CONFIG_HOME/blutui or ~/.config/blutui, and creates it if needed.
+func Dir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// Path returns the path of a file named name within [Dir].
+func Path(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// StateDir returns the directory holding data blutui accumulates while running,
+// such as listening history, which is $XDG_STATE_HOME/blutui or
+// ~/.local/state/blutui, and creates it if needed.
+func StateDir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// StatePath returns the path of a file named name within [StateDir].
+func StatePath(name string) (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// RuntimePath returns the path of a file named name within the directory
+// holding blutui's sockets, which is $XDG_RUNTIME_DIR/blutui or, if that's not
+// set, a directory only the user can access in the system's temporary directory.
+func RuntimePath(name string) (string, error) {
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("blutui-%d", os.Getuid()))
+	if base := os.Getenv("XDG_RUNTIME_DIR"); base != "" {
+		dir = filepath.Join(base, "blutui")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// xdgDir returns the blutui directory within the base directory set in env,
+// falling back to home relative to the user's home directory.
+func xdgDir(env, home string) (string, error) {
+	base := os.Getenv(env)
+	if base == "" {
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		base = filepath.Join(h, home)
+	}
+
+	dir := filepath.Join(base, "blutui")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
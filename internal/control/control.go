@@ -0,0 +1,326 @@
+// Package control serves a Unix domain socket through which other programs,
+// such as shell scripts, drive the running terminal user interface.
+//
+// The socket speaks JSON-RPC 2.0, one request or response per line, e.g.:
+//
+//	{"jsonrpc":"2.0","id":1,"method":"ui.selectArtist","params":{"artist":"Low"}}
+//	{"jsonrpc":"2.0","id":1,"result":true}
+//
+// See [Server] for the list of methods.
+package control
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/mkozjak/blutui/internal/app"
+	"github.com/mkozjak/blutui/internal/library"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/tview"
+)
+
+// SocketFile is the name of the socket within the runtime directory.
+const SocketFile = "control.sock"
+
+// maxRequest limits the length of a single request line.
+const maxRequest = 1 << 20
+
+// Error codes of JSON-RPC 2.0, and codeFailed for requests that were
+// understood, but couldn't be carried out.
+const (
+	codeParse          = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeFailed         = -32000
+)
+
+type appManager interface {
+	app.Updater
+	app.PageViewer
+}
+
+// Player represents the player the socket controls.
+type Player interface {
+	Playpause()
+	Pause()
+	Resume()
+	Stop()
+	Next()
+	Previous()
+	SetVolume(level int)
+	MaxVolume() int
+	ToggleMute()
+	SetRepeatMode(mode int)
+	Status() player.Status
+	Subscribe() <-chan player.Status
+	Unsubscribe(ch <-chan player.Status)
+}
+
+// Library represents a library page whose contents can be queried and played.
+type Library interface {
+	Artists() []string
+	Albums(artist string) []library.AlbumInfo
+	QueueAlbum(artist, album, where string) error
+	SelectArtist(name string) bool
+}
+
+// PageSwitcher represents pages the socket navigates between.
+type PageSwitcher interface {
+	HasPage(name string) bool
+	SwitchToPage(name string) *tview.Pages
+}
+
+// Server serves the control socket. It implements the following methods,
+// with parameters given by name:
+//
+//	player.play, player.pause, player.toggle, player.stop, player.next, player.prev
+//	player.volume {level, delta}     print volume, set it to level or change it by delta
+//	player.mute                      toggle mute
+//	player.repeat {mode}             set repeat mode to all, one or off
+//	player.status                    return player status
+//	player.subscribe                 return player status and send its changes
+//	                                 as player.status notifications
+//	player.unsubscribe               stop sending player.status notifications
+//	library.artists {page}           return artists of a library page, local by default
+//	library.albums {artist, page}    return albums of an artist
+//	library.queueAlbum {artist, album, where, page}
+//	                                 play an album now, next or last, now by default
+//	ui.selectArtist {artist, page}   show and select an artist
+//	ui.switchPage {page}             show a page, such as local, radio or history
+//	ui.currentPage                   return the name of the shown page
+//
+// Requests are carried out on the application's event loop, so they don't
+// race with the user interface.
+type Server struct {
+	app      appManager
+	player   Player
+	libs     map[string]Library
+	pages    PageSwitcher
+	notifier notify.Notifier
+
+	mu       sync.Mutex
+	listener net.Listener
+	path     string
+}
+
+func New(a appManager, p Player, libs map[string]Library, pages PageSwitcher, n notify.Notifier) *Server {
+	return &Server{
+		app:      a,
+		player:   p,
+		libs:     libs,
+		pages:    pages,
+		notifier: n,
+	}
+}
+
+// Listen creates the socket at path and starts accepting connections.
+// A socket left behind by an instance that exited is replaced, while one
+// another instance is listening on is reported as an error.
+func (s *Server) Listen(path string) error {
+	if c, err := net.Dial("unix", path); err == nil {
+		c.Close()
+		return fmt.Errorf("%s: another instance is listening", path)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	s.listener, s.path = l, path
+	s.mu.Unlock()
+
+	go s.accept(l)
+	return nil
+}
+
+// Close stops accepting connections and removes the socket.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	err := s.listener.Close()
+	s.listener = nil
+	os.Remove(s.path)
+
+	return err
+}
+
+func (s *Server) accept(l net.Listener) {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.notifier.Error("accepting control connections", err)
+			}
+
+			return
+		}
+
+		go s.serve(c)
+	}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Error is a JSON-RPC error returned to the client.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// conn is a client connection. Its writes are serialized since status
+// notifications are sent concurrently with responses.
+type conn struct {
+	net.Conn
+
+	mu  sync.Mutex
+	enc *json.Encoder
+
+	// updates receives player's status if the client subscribed to it
+	subMu   sync.Mutex
+	updates <-chan player.Status
+}
+
+func (c *conn) send(v any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.enc.Encode(v); err != nil {
+		// the client went away, which concerns only the log
+		slog.Warn("writing to control connection", "err", err)
+	}
+}
+
+func (s *Server) serve(nc net.Conn) {
+	c := &conn{Conn: nc, enc: json.NewEncoder(nc)}
+
+	defer func() {
+		s.unsubscribe(c)
+		c.Close()
+	}()
+
+	sc := bufio.NewScanner(c)
+	sc.Buffer(nil, maxRequest)
+
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		if r := s.handle(c, line); r != nil {
+			c.send(r)
+		}
+	}
+}
+
+// handle carries out a request and returns its response, which is nil
+// for notifications, i.e. requests without an id.
+func (s *Server) handle(c *conn, line []byte) *response {
+	var req request
+
+	if err := json.Unmarshal(line, &req); err != nil {
+		return errorResponse(nil, &Error{codeParse, err.Error()})
+	}
+
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, &Error{codeInvalidRequest, "invalid request"})
+	}
+
+	m, ok := methods[req.Method]
+
+	var res any
+	var err error
+
+	if !ok {
+		err = &Error{codeMethodNotFound, "method not found: " + req.Method}
+	} else {
+		res, err = m(s, c, req.Params)
+	}
+
+	if req.ID == nil {
+		return nil
+	}
+
+	if err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			e = &Error{codeFailed, err.Error()}
+		}
+
+		return errorResponse(req.ID, e)
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return errorResponse(req.ID, &Error{codeFailed, err.Error()})
+	}
+
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: data}
+}
+
+func errorResponse(id json.RawMessage, e *Error) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	return &response{JSONRPC: "2.0", ID: id, Error: e}
+}
+
+// ui runs f on the application's event loop and waits for it to finish.
+func (s *Server) ui(f func()) {
+	done := make(chan struct{})
+
+	s.app.QueueUpdateDraw(func() {
+		defer close(done)
+		f()
+	})
+
+	<-done
+}
@@ -0,0 +1,352 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkozjak/blutui/internal/library"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/tview"
+)
+
+// fakeApp runs updates right away, as if it was the event loop.
+type fakeApp struct{ page string }
+
+func (f *fakeApp) QueueUpdateDraw(u func()) *tview.Application {
+	u()
+	return nil
+}
+
+func (f *fakeApp) CurrentPage() string { return f.page }
+
+func (f *fakeApp) HasPage(name string) bool { return name == "local" || name == "radio" }
+
+func (f *fakeApp) SwitchToPage(name string) *tview.Pages {
+	f.page = name
+	return nil
+}
+
+// fakePlayer records commands and publishes statuses on demand.
+type fakePlayer struct {
+	mu     sync.Mutex
+	calls  chan string
+	status player.Status
+	subs   []chan player.Status
+}
+
+func (f *fakePlayer) record(format string, a ...any) { f.calls <- fmt.Sprintf(format, a...) }
+
+func (f *fakePlayer) Playpause()             { f.record("playpause") }
+func (f *fakePlayer) Pause()                 { f.record("pause") }
+func (f *fakePlayer) Resume()                { f.record("resume") }
+func (f *fakePlayer) Stop()                  { f.record("stop") }
+func (f *fakePlayer) Next()                  { f.record("next") }
+func (f *fakePlayer) Previous()              { f.record("previous") }
+func (f *fakePlayer) SetVolume(level int)    { f.record("volume %d", level) }
+func (f *fakePlayer) MaxVolume() int         { return 80 }
+func (f *fakePlayer) ToggleMute()            { f.record("mute") }
+func (f *fakePlayer) SetRepeatMode(mode int) { f.record("repeat %d", mode) }
+func (f *fakePlayer) Status() player.Status  { return f.status }
+
+func (f *fakePlayer) Subscribe() <-chan player.Status {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan player.Status, 1)
+	f.subs = append(f.subs, ch)
+	f.calls <- "subscribe"
+
+	return ch
+}
+
+func (f *fakePlayer) Unsubscribe(ch <-chan player.Status) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, s := range f.subs {
+		if s == ch {
+			f.subs = append(f.subs[:i], f.subs[i+1:]...)
+			close(s)
+		}
+	}
+}
+
+func (f *fakePlayer) publish(s player.Status) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, ch := range f.subs {
+		ch <- s
+	}
+}
+
+type fakeLibrary struct {
+	selected string
+	queued   string
+}
+
+func (f *fakeLibrary) Artists() []string { return []string{"Low", "Slowdive"} }
+
+func (f *fakeLibrary) Albums(artist string) []library.AlbumInfo {
+	if artist != "Low" {
+		return nil
+	}
+
+	return []library.AlbumInfo{{Name: "Hey What", Year: 2021, Tracks: []string{"White Horses"}}}
+}
+
+func (f *fakeLibrary) QueueAlbum(artist, album, where string) error {
+	if album != "Hey What" {
+		return errors.New("no such album")
+	}
+
+	f.queued = artist + "/" + album + "/" + where
+	return nil
+}
+
+func (f *fakeLibrary) SelectArtist(name string) bool {
+	f.selected = name
+	return name == "Low"
+}
+
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+	id   int
+}
+
+// call sends a request and returns its response, skipping notifications.
+func (c *client) call(method string, params any) (json.RawMessage, *Error) {
+	c.t.Helper()
+
+	c.id++
+	req := map[string]any{"jsonrpc": "2.0", "id": c.id, "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		var r struct {
+			ID     int
+			Method string
+			Result json.RawMessage
+			Error  *Error
+		}
+
+		if err := c.read(&r); err != nil {
+			c.t.Fatal(err)
+		}
+
+		if r.Method == "" {
+			if r.ID != c.id {
+				c.t.Fatalf("got response to %d, want %d", r.ID, c.id)
+			}
+
+			return r.Result, r.Error
+		}
+	}
+}
+
+func (c *client) read(v any) error {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	line, err := c.r.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(line, v)
+}
+
+func start(t *testing.T) (*Server, *client, *fakePlayer, *fakeLibrary, *fakeApp) {
+	t.Helper()
+
+	fa := &fakeApp{page: "local"}
+	fp := &fakePlayer{calls: make(chan string, 16), status: player.Status{State: "play", Artist: "Low", Volume: 40}}
+	fl := &fakeLibrary{}
+
+	s := New(fa, fp, map[string]Library{"local": fl}, fa, notify.Log)
+	path := filepath.Join(t.TempDir(), SocketFile)
+
+	if err := s.Listen(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	nc, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { nc.Close() })
+
+	return s, &client{t: t, conn: nc, r: bufio.NewReader(nc)}, fp, fl, fa
+}
+
+func TestMethods(t *testing.T) {
+	_, c, fp, fl, fa := start(t)
+
+	commands := []struct {
+		method string
+		params any
+		result string
+		call   string
+	}{
+		{"player.toggle", nil, "true", "playpause"},
+		{"player.prev", nil, "true", "previous"},
+		{"player.mute", nil, "true", "mute"},
+		{"player.volume", map[string]int{"delta": -50}, "0", "volume 0"},
+		{"player.volume", map[string]int{"level": 70}, "70", "volume 70"},
+		{"player.volume", map[string]int{"level": 100}, "80", "volume 80"},
+		{"player.repeat", map[string]string{"mode": "one"}, "true", "repeat 1"},
+	}
+
+	for _, cm := range commands {
+		res, e := c.call(cm.method, cm.params)
+		if e != nil || string(res) != cm.result {
+			t.Errorf("%s: got %s %v, want %s", cm.method, res, e, cm.result)
+		}
+
+		select {
+		case got := <-fp.calls:
+			if got != cm.call {
+				t.Errorf("%s called %q, want %q", cm.method, got, cm.call)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s didn't reach the player", cm.method)
+		}
+	}
+
+	queries := []struct {
+		method string
+		params any
+		result string
+	}{
+		{"player.volume", nil, "40"},
+		{"library.artists", nil, `["Low","Slowdive"]`},
+		{"library.albums", map[string]string{"artist": "Low"},
+			`[{"name":"Hey What","year":2021,"tracks":["White Horses"]}]`},
+		{"library.queueAlbum", map[string]string{"artist": "Low", "album": "Hey What", "where": "next"}, "true"},
+		{"ui.switchPage", map[string]string{"page": "radio"}, "true"},
+		{"ui.currentPage", nil, `"radio"`},
+		{"ui.selectArtist", map[string]string{"artist": "Low"}, "true"},
+	}
+
+	for _, q := range queries {
+		res, e := c.call(q.method, q.params)
+		if e != nil || string(res) != q.result {
+			t.Errorf("%s: got %s %v, want %s", q.method, res, e, q.result)
+		}
+	}
+
+	if fl.queued != "Low/Hey What/next" || fl.selected != "Low" || fa.page != "local" {
+		t.Errorf("queued %q, selected %q on page %q", fl.queued, fl.selected, fa.page)
+	}
+
+	errs := []struct {
+		method string
+		params any
+		code   int
+	}{
+		{"player.dance", nil, codeMethodNotFound},
+		{"player.repeat", map[string]string{"mode": "sometimes"}, codeInvalidParams},
+		{"player.volume", map[string]string{"level": "loud"}, codeInvalidParams},
+		{"library.artists", map[string]string{"page": "radio"}, codeInvalidParams},
+		{"library.albums", map[string]string{"artist": "Nobody"}, codeFailed},
+		{"library.queueAlbum", map[string]string{"artist": "Low", "album": "Nope"}, codeFailed},
+		{"ui.switchPage", map[string]string{"page": "nowhere"}, codeInvalidParams},
+		{"ui.selectArtist", map[string]string{"artist": "Nobody"}, codeFailed},
+	}
+
+	for _, e := range errs {
+		if _, err := c.call(e.method, e.params); err == nil || err.Code != e.code {
+			t.Errorf("%s: got error %v, want code %d", e.method, err, e.code)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	_, c, fp, _, _ := start(t)
+
+	res, e := c.call("player.subscribe", nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	var st struct{ Artist string }
+	if err := json.Unmarshal(res, &st); err != nil || st.Artist != "Low" {
+		t.Fatalf("got %s, want current status", res)
+	}
+
+	<-fp.calls
+	fp.publish(player.Status{State: "pause", Artist: "Slowdive"})
+
+	var n struct {
+		Method string
+		Params struct{ State, Artist string }
+	}
+
+	if err := c.read(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Method != "player.status" || n.Params.State != "pause" || n.Params.Artist != "Slowdive" {
+		t.Errorf("unexpected notification %+v", n)
+	}
+
+	if _, e := c.call("player.unsubscribe", nil); e != nil {
+		t.Fatal(e)
+	}
+
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	if len(fp.subs) != 0 {
+		t.Errorf("%d subscriptions left", len(fp.subs))
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	_, c, _, _, _ := start(t)
+
+	fmt.Fprintln(c.conn, `{"jsonrpc":`)
+	fmt.Fprintln(c.conn, `{"id":1,"method":"player.status"}`)
+
+	for _, code := range []int{codeParse, codeInvalidRequest} {
+		var r struct{ Error *Error }
+		if err := c.read(&r); err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Error == nil || r.Error.Code != code {
+			t.Errorf("got error %v, want code %d", r.Error, code)
+		}
+	}
+
+	// notifications get no response
+	fmt.Fprintln(c.conn, `{"jsonrpc":"2.0","method":"player.status"}`)
+
+	if _, e := c.call("player.status", nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestListenTwice(t *testing.T) {
+	s, _, _, _, _ := start(t)
+
+	if err := New(nil, nil, nil, nil, nil).Listen(s.path); err == nil {
+		t.Error("second instance listened on the same socket")
+	}
+}
@@ -0,0 +1,296 @@
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mkozjak/blutui/internal/cli"
+	"github.com/mkozjak/blutui/internal/library"
+)
+
+// method carries out a request given its raw parameters.
+type method func(s *Server, c *conn, params json.RawMessage) (any, error)
+
+var methods = map[string]method{
+	"player.play":        command(Player.Resume),
+	"player.pause":       command(Player.Pause),
+	"player.toggle":      command(Player.Playpause),
+	"player.stop":        command(Player.Stop),
+	"player.next":        command(Player.Next),
+	"player.prev":        command(Player.Previous),
+	"player.mute":        command(Player.ToggleMute),
+	"player.volume":      (*Server).volume,
+	"player.repeat":      (*Server).repeat,
+	"player.status":      (*Server).status,
+	"player.subscribe":   (*Server).subscribe,
+	"player.unsubscribe": (*Server).unsubscribeMethod,
+	"library.artists":    (*Server).artists,
+	"library.albums":     (*Server).albums,
+	"library.queueAlbum": (*Server).queueAlbum,
+	"ui.selectArtist":    (*Server).selectArtist,
+	"ui.switchPage":      (*Server).switchPage,
+	"ui.currentPage":     (*Server).currentPage,
+}
+
+// decode unmarshals params into v, which holds defaults of omitted ones.
+func decode(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{codeInvalidParams, err.Error()}
+	}
+
+	return nil
+}
+
+func invalidParams(format string, a ...any) error {
+	return &Error{codeInvalidParams, fmt.Sprintf(format, a...)}
+}
+
+// command returns a method that runs a player command taking no parameters.
+// Like key bindings, commands are started from the event loop, so they are
+// sent to the player in order with the user's, and run in the background so
+// that a slow player doesn't hold it up.
+func command(f func(Player)) method {
+	return func(s *Server, _ *conn, _ json.RawMessage) (any, error) {
+		s.ui(func() { go f(s.player) })
+		return true, nil
+	}
+}
+
+func (s *Server) volume(_ *conn, params json.RawMessage) (any, error) {
+	var p struct {
+		Level *int `json:"level"`
+		Delta *int `json:"delta"`
+	}
+
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	v := s.player.Status().Volume
+
+	switch {
+	case p.Level != nil && p.Delta != nil:
+		return nil, invalidParams("level and delta are mutually exclusive")
+	case p.Level != nil:
+		v = *p.Level
+	case p.Delta != nil:
+		v += *p.Delta
+	default:
+		return v, nil
+	}
+
+	v = min(max(v, 0), s.player.MaxVolume())
+	s.ui(func() { go s.player.SetVolume(v) })
+
+	return v, nil
+}
+
+func (s *Server) repeat(_ *conn, params json.RawMessage) (any, error) {
+	var p struct {
+		Mode string `json:"mode"`
+	}
+
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	m, ok := cli.ParseRepeatMode(p.Mode)
+	if !ok {
+		return nil, invalidParams("unknown repeat mode %q", p.Mode)
+	}
+
+	s.ui(func() { go s.player.SetRepeatMode(m) })
+	return true, nil
+}
+
+func (s *Server) status(_ *conn, _ json.RawMessage) (any, error) {
+	return cli.StatusOf(s.player.Status()), nil
+}
+
+// subscribe sends player's status changes to the client until it unsubscribes
+// or disconnects.
+func (s *Server) subscribe(c *conn, _ json.RawMessage) (any, error) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.updates == nil {
+		ch := s.player.Subscribe()
+		c.updates = ch
+
+		go func() {
+			for st := range ch {
+				c.send(notification{JSONRPC: "2.0", Method: "player.status", Params: cli.StatusOf(st)})
+			}
+		}()
+	}
+
+	return cli.StatusOf(s.player.Status()), nil
+}
+
+func (s *Server) unsubscribeMethod(c *conn, _ json.RawMessage) (any, error) {
+	s.unsubscribe(c)
+	return true, nil
+}
+
+func (s *Server) unsubscribe(c *conn) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.updates != nil {
+		s.player.Unsubscribe(c.updates)
+		c.updates = nil
+	}
+}
+
+// library returns the library shown on page, the local one if it's empty.
+func (s *Server) library(page string) (Library, error) {
+	if page == "" {
+		page = "local"
+	}
+
+	l, ok := s.libs[page]
+	if !ok {
+		return nil, invalidParams("no library page %q", page)
+	}
+
+	return l, nil
+}
+
+func (s *Server) artists(_ *conn, params json.RawMessage) (any, error) {
+	var p struct {
+		Page string `json:"page"`
+	}
+
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	l, err := s.library(p.Page)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	s.ui(func() { res = append(res, l.Artists()...) })
+
+	return res, nil
+}
+
+func (s *Server) albums(_ *conn, params json.RawMessage) (any, error) {
+	var p struct {
+		Artist string `json:"artist"`
+		Page   string `json:"page"`
+	}
+
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	l, err := s.library(p.Page)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []library.AlbumInfo
+	s.ui(func() { res = l.Albums(p.Artist) })
+
+	if res == nil {
+		return nil, fmt.Errorf("no artist %q", p.Artist)
+	}
+
+	return res, nil
+}
+
+func (s *Server) queueAlbum(_ *conn, params json.RawMessage) (any, error) {
+	p := struct {
+		Artist string `json:"artist"`
+		Album  string `json:"album"`
+		Where  string `json:"where"`
+		Page   string `json:"page"`
+	}{Where: "now"}
+
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	l, err := s.library(p.Page)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ui(func() { err = l.QueueAlbum(p.Artist, p.Album, p.Where) })
+	if err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+func (s *Server) selectArtist(_ *conn, params json.RawMessage) (any, error) {
+	var p struct {
+		Artist string `json:"artist"`
+		Page   string `json:"page"`
+	}
+
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	if p.Page == "" {
+		p.Page = "local"
+	}
+
+	l, err := s.library(p.Page)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	s.ui(func() {
+		s.pages.SwitchToPage(p.Page)
+		found = l.SelectArtist(p.Artist)
+	})
+
+	if !found {
+		return nil, fmt.Errorf("artist not in library: %s", p.Artist)
+	}
+
+	return true, nil
+}
+
+func (s *Server) switchPage(_ *conn, params json.RawMessage) (any, error) {
+	var p struct {
+		Page string `json:"page"`
+	}
+
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	var err error
+	s.ui(func() {
+		if !s.pages.HasPage(p.Page) {
+			err = invalidParams("no page %q", p.Page)
+			return
+		}
+
+		s.pages.SwitchToPage(p.Page)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+func (s *Server) currentPage(_ *conn, _ json.RawMessage) (any, error) {
+	var n string
+	s.ui(func() { n = s.app.CurrentPage() })
+
+	return n, nil
+}
@@ -0,0 +1,325 @@
+// Package fuzzy implements a ranked matcher used for searching the library.
+//
+// A query is matched against a target in several ways, from the strongest to
+// the weakest: an exact match, a prefix of the whole target, a prefix of one
+// of the target's words, a substring, an fzf-style subsequence and finally a
+// typo-tolerant match against the target's words. Both the query and the
+// target are lowercased and stripped of accents before matching.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mkozjak/blutui/internal"
+)
+
+// Kind describes how a query matched a target.
+// Kinds are ordered so that a stronger match has a higher value.
+type Kind int
+
+const (
+	None Kind = iota
+	Typo
+	Subsequence
+	Substring
+	WordPrefix
+	Prefix
+	Exact
+)
+
+// String returns a human readable name of the match kind.
+func (k Kind) String() string {
+	switch k {
+	case Typo:
+		return "typo"
+	case Subsequence:
+		return "subsequence"
+	case Substring:
+		return "substring"
+	case WordPrefix:
+		return "word prefix"
+	case Prefix:
+		return "prefix"
+	case Exact:
+		return "exact"
+	}
+
+	return "none"
+}
+
+// A Match holds a single ranked result returned by [Rank].
+type Match struct {
+	// Target is the original, unnormalized target string.
+	Target string
+	// Index is the position of Target in the slice given to [Rank].
+	Index int
+	Kind  Kind
+	// Score orders matches of the same Kind. Higher is better.
+	Score int
+}
+
+// Scoring constants for subsequence matching, loosely following fzf.
+const (
+	scoreMatch       = 16
+	bonusConsecutive = 8
+	bonusBoundary    = 8
+	penaltyGap       = 1
+)
+
+// Normalize lowercases s and strips its accent marks, so that
+// "Motörhead" and "motorhead" compare as equal.
+func Normalize(s string) string {
+	return internal.RemoveAccents(strings.ToLower(s))
+}
+
+// Score matches query against target and returns the kind of the match
+// along with its score. Kind is [None] if the query does not match.
+func Score(query, target string) (Kind, int) {
+	q := []rune(Normalize(strings.TrimSpace(query)))
+	t := []rune(Normalize(target))
+
+	if len(q) == 0 || len(t) == 0 {
+		return None, 0
+	}
+
+	qs := string(q)
+	ts := string(t)
+
+	switch {
+	case qs == ts:
+		return Exact, 0
+	case strings.HasPrefix(ts, qs):
+		// Prefer targets that are closer in length to the query
+		return Prefix, -(len(t) - len(q))
+	}
+
+	for _, w := range words(t) {
+		if strings.HasPrefix(string(t[w:]), qs) {
+			return WordPrefix, -w
+		}
+	}
+
+	if i := strings.Index(ts, qs); i >= 0 {
+		return Substring, -len([]rune(ts[:i]))
+	}
+
+	if s, ok := subsequence(q, t); ok {
+		return Subsequence, s
+	}
+
+	if d, ok := typo(q, t); ok {
+		return Typo, -d
+	}
+
+	return None, 0
+}
+
+// Rank matches query against every target and returns the matching ones
+// sorted from the best to the worst match. Matches of equal kind and score
+// are ordered by target length and then alphabetically.
+func Rank(query string, targets []string) []Match {
+	var m []Match
+
+	for i, t := range targets {
+		k, s := Score(query, t)
+		if k == None {
+			continue
+		}
+
+		m = append(m, Match{Target: t, Index: i, Kind: k, Score: s})
+	}
+
+	sort.SliceStable(m, func(i, j int) bool {
+		if m[i].Kind != m[j].Kind {
+			return m[i].Kind > m[j].Kind
+		}
+
+		if m[i].Score != m[j].Score {
+			return m[i].Score > m[j].Score
+		}
+
+		li, lj := len([]rune(m[i].Target)), len([]rune(m[j].Target))
+		if li != lj {
+			return li < lj
+		}
+
+		return strings.ToLower(m[i].Target) < strings.ToLower(m[j].Target)
+	})
+
+	return m
+}
+
+// isBoundary reports whether the rune at index i of t starts a word.
+func isBoundary(t []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	p := t[i-1]
+	return !unicode.IsLetter(p) && !unicode.IsDigit(p)
+}
+
+// words returns indices of runes in t that start a word.
+func words(t []rune) []int {
+	var w []int
+
+	for i, r := range t {
+		if (unicode.IsLetter(r) || unicode.IsDigit(r)) && isBoundary(t, i) {
+			w = append(w, i)
+		}
+	}
+
+	return w
+}
+
+// subsequence matches all of q's runes in order within t. The leftmost
+// match is found first and then tightened by walking back from its end,
+// the same way fzf's v1 algorithm does. Consecutive runes and runes at
+// word boundaries are rewarded while gaps between them are penalized.
+func subsequence(q, t []rune) (int, bool) {
+	qi := 0
+	end := -1
+
+	for ti := 0; ti < len(t); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+
+		qi++
+		if qi == len(q) {
+			end = ti
+			break
+		}
+	}
+
+	if end < 0 {
+		return 0, false
+	}
+
+	start := end
+	qi = len(q) - 1
+
+	for ti := end; ti >= 0; ti-- {
+		if t[ti] != q[qi] {
+			continue
+		}
+
+		start = ti
+		qi--
+		if qi < 0 {
+			break
+		}
+	}
+
+	score := 0
+	prev := -1
+	qi = 0
+
+	for ti := start; ti <= end && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+
+		score += scoreMatch
+
+		if isBoundary(t, ti) {
+			score += bonusBoundary
+		}
+
+		if prev >= 0 {
+			if ti == prev+1 {
+				score += bonusConsecutive
+			} else {
+				score -= penaltyGap * (ti - prev - 1)
+			}
+		}
+
+		prev = ti
+		qi++
+	}
+
+	return score, true
+}
+
+// maxTypos returns the number of edits allowed for a query of length n.
+// Short queries have to be spelled correctly, otherwise almost anything
+// would match them. Longer ones are allowed roughly one typo per word.
+func maxTypos(n int) int {
+	switch {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	}
+
+	return n / 4
+}
+
+// typo compares q against every word in t, and against the word's
+// prefix of the same length, returning the smallest edit distance found.
+func typo(q, t []rune) (int, bool) {
+	limit := maxTypos(len(q))
+	if limit == 0 {
+		return 0, false
+	}
+
+	best := limit + 1
+
+	candidates := [][]rune{t}
+	for _, w := range words(t) {
+		end := w
+		for end < len(t) && !unicode.IsSpace(t[end]) {
+			end++
+		}
+
+		candidates = append(candidates, t[w:end])
+	}
+
+	for _, c := range candidates {
+		best = min(best, distance(q, c))
+
+		if len(c) > len(q) {
+			best = min(best, distance(q, c[:len(q)]))
+		}
+	}
+
+	if best > limit {
+		return 0, false
+	}
+
+	return best, true
+}
+
+// distance returns the optimal string alignment distance between a and b,
+// which is the Levenshtein distance extended with transpositions of two
+// adjacent runes. Every rune is matched at most once.
+func distance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
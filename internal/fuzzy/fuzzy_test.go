@@ -0,0 +1,147 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScoreKind(t *testing.T) {
+	type test struct {
+		query  string
+		target string
+		want   Kind
+	}
+
+	tests := []test{
+		{query: "korn", target: "Korn", want: Exact},
+		{query: "motorhead", target: "Motörhead", want: Exact},
+		{query: "pink", target: "Pink Floyd", want: Prefix},
+		{query: "floyd", target: "Pink Floyd", want: WordPrefix},
+		{query: "bizk", target: "Limp Bizkit", want: WordPrefix},
+		{query: "loyd", target: "Pink Floyd", want: Substring},
+		{query: "dmth", target: "Dream Theater", want: Subsequence},
+		{query: "pink", target: "P!nk", want: Typo},
+		{query: "mettalica", target: "Metallica", want: Typo},
+		{query: "drmea theter", target: "Dream Theater", want: Typo},
+		{query: "korn", target: "Cynic", want: None},
+		{query: "abc", target: "Abd", want: None},
+		{query: "", target: "Korn", want: None},
+		{query: "korn", target: "", want: None},
+	}
+
+	for _, tc := range tests {
+		got, _ := Score(tc.query, tc.target)
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Fatalf("%q vs %q: expected: %v, got: %v", tc.query, tc.target, tc.want, got)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	type test struct {
+		query   string
+		targets []string
+		want    []string
+	}
+
+	tests := []test{
+		{
+			query:   "pink",
+			targets: []string{"P!nk", "Pink Floyd", "Korn", "Pinkerton"},
+			want:    []string{"Pinkerton", "Pink Floyd", "P!nk"},
+		},
+		{
+			query:   "the",
+			targets: []string{"Nothing", "The Cure", "Theatre of Tragedy", "Dream Theater"},
+			want:    []string{"The Cure", "Theatre of Tragedy", "Dream Theater"},
+		},
+		{
+			query:   "sigur",
+			targets: []string{"Sigur Rós", "Sigurd"},
+			want:    []string{"Sigurd", "Sigur Rós"},
+		},
+		{
+			query:   "bjork",
+			targets: []string{"Björk", "Bjørn"},
+			want:    []string{"Björk"},
+		},
+		{
+			query:   "zzz",
+			targets: []string{"Korn", "Camel"},
+			want:    nil,
+		},
+	}
+
+	for _, tc := range tests {
+		var got []string
+		for _, m := range Rank(tc.query, tc.targets) {
+			got = append(got, m.Target)
+		}
+
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Fatalf("%q: expected: %v, got: %v", tc.query, tc.want, got)
+		}
+	}
+}
+
+func TestRankIndex(t *testing.T) {
+	targets := []string{"Camel", "Caravan", "Can"}
+
+	for _, m := range Rank("ca", targets) {
+		if targets[m.Index] != m.Target {
+			t.Fatalf("expected: %v, got: %v", targets[m.Index], m.Target)
+		}
+	}
+}
+
+func TestSubsequenceScore(t *testing.T) {
+	type test struct {
+		query  string
+		better string
+		worse  string
+	}
+
+	tests := []test{
+		// consecutive runes beat scattered ones
+		{query: "mth", better: "Mothership", worse: "Mighty Thorn"},
+		// word boundaries beat runes in the middle of a word
+		{query: "dt", better: "Dream Theater", worse: "Edith"},
+	}
+
+	for _, tc := range tests {
+		kb, sb := Score(tc.query, tc.better)
+		kw, sw := Score(tc.query, tc.worse)
+
+		if kb != Subsequence || kw != Subsequence {
+			t.Fatalf("%q: expected subsequence matches, got: %v, %v", tc.query, kb, kw)
+		}
+
+		if sb <= sw {
+			t.Fatalf("%q: expected %q (%d) to score above %q (%d)", tc.query, tc.better, sb, tc.worse, sw)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	type test struct {
+		a    string
+		b    string
+		want int
+	}
+
+	tests := []test{
+		{a: "korn", b: "korn", want: 0},
+		{a: "korn", b: "kron", want: 1},
+		{a: "pink", b: "p!nk", want: 1},
+		{a: "faremviel", b: "farmville", want: 3},
+		{a: "", b: "abc", want: 3},
+		{a: "ßø", b: "øß", want: 1},
+	}
+
+	for _, tc := range tests {
+		got := distance([]rune(tc.a), []rune(tc.b))
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Fatalf("%q vs %q: expected: %v, got: %v", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
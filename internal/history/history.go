@@ -0,0 +1,197 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/app"
+	"github.com/mkozjak/blutui/internal/keymap"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/theme"
+	"github.com/mkozjak/tview"
+)
+
+// PageName is the name of the page holding [History].
+const PageName = "history"
+
+const (
+	// maxRecent limits the number of recent plays shown.
+	maxRecent = 500
+	// topEntries is the number of top artists and albums shown.
+	topEntries = 10
+	// topDays is the number of days top artists and albums are computed over.
+	topDays = 30
+	// chartDays is the number of days plays per day are shown for.
+	chartDays = 14
+	// chartWidth is the width of the longest bar in the plays per day chart.
+	chartWidth = 30
+)
+
+type appManager interface {
+	app.Focuser
+	app.PageViewer
+	app.Updater
+}
+
+// History represents a page that shows recently played tracks along with
+// listening statistics computed from the local listening history.
+type History struct {
+	container *tview.Flex
+	app       appManager
+	store     *Store
+	notifier  notify.Notifier
+	keys      *keymap.Keymap
+
+	recentPane *tview.Table
+	statsPane  *tview.TextView
+}
+
+func New(a appManager, st *Store, n notify.Notifier, k *keymap.Keymap) *History {
+	return &History{
+		app:      a,
+		store:    st,
+		notifier: n,
+		keys:     k,
+	}
+}
+
+func (h *History) CreateContainer() *tview.Flex {
+	h.recentPane = tview.NewTable().
+		SetSelectable(true, false).
+		SetSelectedStyle(theme.Current.SelectedStyle(true))
+
+	h.recentPane.SetTitle(" [::b]Recently played ").
+		SetBorder(true).
+		SetBorderColor(theme.Current.Border).
+		SetBackgroundColor(tcell.ColorDefault).
+		SetTitleAlign(tview.AlignLeft).
+		SetCustomBorders(internal.CustomBorders).
+		SetBlurFunc(func() {
+			h.app.SetPrevFocused(PageName)
+		})
+
+	h.statsPane = tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+
+	h.statsPane.SetTitle(" [::b]Statistics ").
+		SetBorder(true).
+		SetBorderColor(theme.Current.Border).
+		SetBackgroundColor(tcell.ColorDefault).
+		SetTitleAlign(tview.AlignLeft).
+		SetCustomBorders(internal.CustomBorders)
+
+	h.container = tview.NewFlex().SetDirection(tview.FlexRow).
+		// left and right pane
+		AddItem(tview.NewFlex().
+			AddItem(h.recentPane, 0, 3, true).
+			AddItem(h.statsPane, 0, 2, false), 0, 1, true)
+
+	h.container.SetInputCapture(h.KeyboardHandler)
+
+	return h.container
+}
+
+// Refresh reloads the listening history and redraws the page.
+func (h *History) Refresh() {
+	plays, err := h.store.Load()
+	if err != nil {
+		h.notifier.Error("loading listening history", err)
+		return
+	}
+
+	st := Summarize(plays, time.Now(), topEntries, topDays, chartDays)
+
+	h.app.QueueUpdateDraw(func() {
+		h.drawRecent(plays)
+		h.statsPane.SetText(formatStats(st))
+	})
+}
+
+// drawRecent draws plays, the most recent one first.
+func (h *History) drawRecent(plays []Play) {
+	row, _ := h.recentPane.GetSelection()
+	h.recentPane.Clear()
+
+	for i := 0; i < len(plays) && i < maxRecent; i++ {
+		p := plays[len(plays)-1-i]
+
+		h.recentPane.SetCell(i, 0, tview.NewTableCell(p.Time.Local().Format("Jan 02 15:04")).
+			SetTextColor(theme.Current.Dim).
+			SetTransparency(true))
+
+		h.recentPane.SetCell(i, 1, tview.NewTableCell(internal.EscapeStyleTag(p.Artist)).
+			SetTextColor(theme.Current.Accent).
+			SetMaxWidth(30).
+			SetTransparency(true))
+
+		h.recentPane.SetCell(i, 2, tview.NewTableCell(internal.EscapeStyleTag(p.Track)).
+			SetTextColor(theme.Current.Text).
+			SetExpansion(1).
+			SetTransparency(true))
+	}
+
+	if row >= h.recentPane.GetRowCount() {
+		row = h.recentPane.GetRowCount() - 1
+	}
+
+	if row < 0 {
+		row = 0
+	}
+
+	h.recentPane.Select(row, 0)
+}
+
+func formatStats(st Stats) string {
+	var b strings.Builder
+	dim := theme.Current.Tag(theme.Current.Dim)
+
+	b.WriteString("[::b]Listening time[::-]\n")
+	for _, p := range st.Periods {
+		fmt.Fprintf(&b, "  %-9s %5d plays  %s\n", fmt.Sprintf("%d days", p.Days), p.Plays, formatHours(p.Seconds))
+	}
+
+	fmt.Fprintf(&b, "\n[::b]Top artists[::-] %s(%d days)[-]\n", dim, topDays)
+	writeCounts(&b, st.TopArtists)
+
+	fmt.Fprintf(&b, "\n[::b]Top albums[::-] %s(%d days)[-]\n", dim, topDays)
+	writeCounts(&b, st.TopAlbums)
+
+	b.WriteString("\n[::b]Plays per day[::-]\n")
+
+	most := 0
+	for _, d := range st.PerDay {
+		most = max(most, d.Plays)
+	}
+
+	for _, d := range st.PerDay {
+		bar := 0
+		if most > 0 {
+			bar = d.Plays * chartWidth / most
+		}
+
+		fmt.Fprintf(&b, "  %s %s%s[-] %d\n", d.Date.Format("Mon 02"), theme.Current.Tag(theme.Current.Accent),
+			strings.Repeat("█", bar), d.Plays)
+	}
+
+	return b.String()
+}
+
+func writeCounts(b *strings.Builder, c []Count) {
+	if len(c) == 0 {
+		b.WriteString("  " + theme.Current.Tag(theme.Current.Dim) + "nothing played yet[-]\n")
+		return
+	}
+
+	for i, e := range c {
+		fmt.Fprintf(b, "  %2d. %s %s%d[-]\n", i+1, tview.Escape(e.Name), theme.Current.Tag(theme.Current.Dim), e.Plays)
+	}
+}
+
+// formatHours formats secs as hours and minutes, e.g. "12h 05m".
+func formatHours(secs int) string {
+	return fmt.Sprintf("%dh %02dm", secs/3600, secs%3600/60)
+}
@@ -0,0 +1,25 @@
+package history
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mkozjak/blutui/internal/keymap"
+)
+
+func (h *History) KeyboardHandler(event *tcell.EventKey) *tcell.EventKey {
+	switch h.keys.Action(event, keymap.Navigation) {
+	case keymap.PageUp:
+		return tcell.NewEventKey(tcell.KeyPgUp, 0, tcell.ModNone)
+	case keymap.PageDown:
+		return tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone)
+	case keymap.Down:
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case keymap.Up:
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	case keymap.Top:
+		return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+	case keymap.Bottom:
+		return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+	}
+
+	return event
+}
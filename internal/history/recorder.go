@@ -0,0 +1,202 @@
+// Package history records tracks played on the player into a local listening
+// history and shows it, along with listening statistics, on a page.
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+const (
+	// minTrackLen is the length of the shortest track worth recording.
+	minTrackLen = 30
+	// maxThreshold is the longest time a track has to be listened to in order
+	// to be recorded. It also applies to streams that don't have a length.
+	maxThreshold = 4 * 60
+	// rewindSecs is how far playing position has to jump back for a track
+	// to be counted as played again, e.g. when repeating a single track.
+	rewindSecs = 5
+)
+
+// listen holds the state of the track currently being listened to.
+type listen struct {
+	key       string
+	play      Play
+	trackLen  int
+	threshold int
+	start     int // position streams were tuned in at
+	secs      int
+	at        time.Time
+	playing   bool
+	recorded  bool
+}
+
+// Recorder turns player status updates into recorded plays. A track is
+// recorded once it is listened to for half of its length or four minutes,
+// whichever comes first, and at most once until another track starts playing
+// or the same one starts over. The time it was listened to is updated once it
+// stops or another track starts.
+type Recorder struct {
+	store    *Store
+	notifier notify.Notifier
+	now      func() time.Time
+	onRecord func(Play)
+	etag     string
+	cur      listen
+}
+
+// NewRecorder returns a [Recorder] that appends plays to st, reporting
+// failures to n, and calls onRecord, if not nil, with each of them, even if
+// storing it failed.
+func NewRecorder(st *Store, n notify.Notifier, onRecord func(Play)) *Recorder {
+	return &Recorder{
+		store:    st,
+		notifier: n,
+		now:      time.Now,
+		onRecord: onRecord,
+	}
+}
+
+// Listen consumes player status updates given its read-only channel.
+func (r *Recorder) Listen(ch <-chan player.Status) {
+	for s := range ch {
+		r.update(s)
+	}
+}
+
+func (r *Recorder) update(s player.Status) {
+	if s.State == "offline" || s.State == "ctrlerr" {
+		return
+	}
+
+	// account for the time played since the previous update
+	r.check()
+
+	// unchanged statuses, e.g. on long polling timeouts, carry no new information
+	if s.ETag != "" && s.ETag == r.etag {
+		return
+	}
+
+	r.etag = s.ETag
+
+	key, _ := FromStatus(s)
+	changed := key != r.cur.key || (r.cur.recorded && s.Secs+rewindSecs < r.cur.secs)
+
+	if changed || s.State == "stop" {
+		r.finish()
+	}
+
+	if changed {
+		r.cur = newListen(s)
+		r.cur.play.Time = r.now().Add(-time.Duration(s.Secs-r.cur.start) * time.Second)
+	}
+
+	r.cur.secs = s.Secs
+	r.cur.at = r.now()
+	r.cur.playing = s.State == "play" || s.State == "stream"
+
+	r.check()
+}
+
+// FromStatus returns the play described by player status s, without its time,
+// along with a key that changes whenever another track starts playing.
+func FromStatus(s player.Status) (string, Play) {
+	if s.State == "stream" {
+		// radio stations put their metadata into title lines
+		return fmt.Sprintf("%s|%s|%s", s.Service, s.Title2, s.Title3),
+			Play{Artist: s.Title3, Track: s.Title2, Service: s.Service, Length: s.TrackLen}
+	}
+
+	return fmt.Sprintf("%s|%s|%s|%s|%d", s.Service, s.Artist, s.Album, s.Track, s.Song),
+		Play{Artist: s.Artist, Album: s.Album, Track: s.Track, Service: s.Service, Length: s.TrackLen}
+}
+
+func newListen(s player.Status) listen {
+	key, p := FromStatus(s)
+	l := listen{
+		key:      key,
+		trackLen: s.TrackLen,
+		play:     p,
+	}
+
+	// the position of streams is the time since the station was tuned in
+	if s.State == "stream" {
+		l.start = s.Secs
+	}
+
+	switch {
+	case l.play.Track == "":
+		l.threshold = -1
+	case s.TrackLen == 0:
+		l.threshold = maxThreshold
+	case s.TrackLen < minTrackLen:
+		l.threshold = -1
+	default:
+		l.threshold = min(s.TrackLen/2, maxThreshold)
+	}
+
+	return l
+}
+
+// listened returns the playing position of the current track, interpolated
+// since the last status update while playing, which for streams starts when
+// the track did.
+func (r *Recorder) listened() int {
+	secs := r.cur.secs
+	if r.cur.playing {
+		secs += int(r.now().Sub(r.cur.at).Seconds())
+	}
+
+	if r.cur.trackLen > 0 && secs > r.cur.trackLen {
+		secs = r.cur.trackLen
+	}
+
+	return max(secs-r.cur.start, 0)
+}
+
+// check records the current track if it crossed the listen threshold.
+func (r *Recorder) check() {
+	if r.cur.recorded || r.cur.threshold < 0 || r.cur.key == "" {
+		return
+	}
+
+	secs := r.listened()
+	if secs < r.cur.threshold {
+		return
+	}
+
+	r.cur.recorded = true
+
+	r.cur.play.Seconds = secs
+	p := r.cur.play
+
+	if err := r.store.Append(p); err != nil {
+		r.notifier.Error("recording play", err)
+	}
+
+	if r.onRecord != nil {
+		r.onRecord(p)
+	}
+}
+
+// finish updates the recorded play of the current track with the time it was
+// listened to, once it stops or another track starts.
+func (r *Recorder) finish() {
+	if !r.cur.recorded {
+		return
+	}
+
+	secs := r.listened()
+	if secs <= r.cur.play.Seconds {
+		return
+	}
+
+	r.cur.play.Seconds = secs
+
+	if err := r.store.UpdateLast(r.cur.play); err != nil {
+		r.notifier.Warn("updating listening time", err, "track", r.cur.play.Track)
+	}
+}
@@ -0,0 +1,140 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+func TestRecorder(t *testing.T) {
+	st := NewStore(filepath.Join(t.TempDir(), File))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	r := NewRecorder(st, notify.Log, nil)
+	r.now = func() time.Time { return now }
+
+	song := func(etag, track string, secs int) player.Status {
+		return player.Status{ETag: etag, State: "play", Artist: "Low", Album: "Hey What",
+			Track: track, TrackLen: 300, Secs: secs}
+	}
+
+	steps := []struct {
+		after  time.Duration
+		status player.Status
+	}{
+		{0, song("1", "White Horses", 0)},
+		// same etag is ignored
+		{10 * time.Second, song("1", "White Horses", 0)},
+		// threshold is half of track length, crossed by interpolating
+		{140 * time.Second, song("2", "White Horses", 150)},
+		{10 * time.Second, song("3", "White Horses", 160)},
+		// skipped before crossing the threshold
+		{0, song("4", "I Can Wait", 0)},
+		{30 * time.Second, song("5", "All Night", 0)},
+		// paused time is not counted
+		{10 * time.Second, player.Status{ETag: "6", State: "pause", Artist: "Low", Album: "Hey What",
+			Track: "All Night", TrackLen: 300, Secs: 10}},
+		{time.Hour, song("7", "All Night", 10)},
+		// long poll timeout with the same etag still crosses the threshold
+		{150 * time.Second, song("7", "All Night", 10)},
+	}
+
+	for _, s := range steps {
+		now = now.Add(s.after)
+		r.update(s.status)
+	}
+
+	plays, err := st.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// White Horses was listened to until I Can Wait started
+	want := []Play{{Track: "White Horses", Seconds: 160}, {Track: "All Night", Seconds: 160}}
+	if len(plays) != len(want) {
+		t.Fatalf("got %d plays %v, want %v", len(plays), plays, want)
+	}
+
+	for i, p := range plays {
+		if p.Track != want[i].Track || p.Seconds != want[i].Seconds || p.Length != 300 {
+			t.Errorf("play %d = %q (%ds of %ds), want %q (%ds of 300s)",
+				i, p.Track, p.Seconds, p.Length, want[i].Track, want[i].Seconds)
+		}
+	}
+}
+
+func TestRecorderSkippedAtThreshold(t *testing.T) {
+	st := NewStore(filepath.Join(t.TempDir(), File))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var recorded []Play
+	r := NewRecorder(st, notify.Log, func(p Play) { recorded = append(recorded, p) })
+	r.now = func() time.Time { return now }
+
+	r.update(player.Status{ETag: "1", State: "play", Artist: "Low", Track: "Sunflower", TrackLen: 240})
+	now = now.Add(2 * time.Minute)
+	r.update(player.Status{ETag: "2", State: "play", Artist: "Low", Track: "Whitetail", TrackLen: 240})
+
+	plays, err := st.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plays) != 1 || plays[0].Seconds != 120 || plays[0].Length != 240 {
+		t.Fatalf("got plays %+v, want Sunflower listened to for 120s", plays)
+	}
+
+	if len(recorded) != 1 || recorded[0] != plays[0] {
+		t.Errorf("recorded %+v, stored %+v", recorded, plays)
+	}
+
+	// streams count from the time they were tuned in
+	now = now.Add(30 * time.Second)
+	r.update(player.Status{ETag: "3", State: "stop", Artist: "Low", Track: "Whitetail", TrackLen: 240, Secs: 30})
+	now = now.Add(time.Hour)
+	r.update(player.Status{ETag: "4", State: "stream", Service: "Radio Paradise",
+		Title2: "Blue", Title3: "Joni Mitchell", Secs: 3600})
+	now = now.Add(5 * time.Minute)
+	r.update(player.Status{ETag: "5", State: "stream", Service: "Radio Paradise",
+		Title2: "River", Title3: "Joni Mitchell", Secs: 3900})
+
+	if plays, _ = st.Load(); len(plays) != 2 || plays[1].Seconds != 300 || !plays[1].Time.Equal(now.Add(-5*time.Minute)) {
+		t.Errorf("got stream play %+v, want Blue listened to for 300s", plays[len(plays)-1])
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	at := func(daysAgo int) time.Time { return now.AddDate(0, 0, -daysAgo) }
+
+	plays := []Play{
+		{Time: at(100), Artist: "Low", Album: "Things We Lost in the Fire", Track: "Sunflower", Seconds: 240},
+		{Time: at(20), Artist: "Low", Album: "Hey What", Track: "Days Like These", Seconds: 300},
+		{Time: at(3), Artist: "Slowdive", Album: "Souvlaki", Track: "Alison", Seconds: 230},
+		{Time: at(0), Artist: "Low", Album: "Hey What", Track: "White Horses", Seconds: 330},
+	}
+
+	st := Summarize(plays, now, 10, 30, 7)
+
+	periods := []Period{{7, 2, 560}, {30, 3, 860}, {365, 4, 1100}}
+	for i, p := range periods {
+		if st.Periods[i] != p {
+			t.Errorf("period %d = %+v, want %+v", i, st.Periods[i], p)
+		}
+	}
+
+	if len(st.TopArtists) != 2 || st.TopArtists[0] != (Count{"Low", 2}) {
+		t.Errorf("top artists = %v", st.TopArtists)
+	}
+
+	if len(st.TopAlbums) != 2 || st.TopAlbums[0] != (Count{"Low - Hey What", 2}) {
+		t.Errorf("top albums = %v", st.TopAlbums)
+	}
+
+	if st.PerDay[0].Plays != 1 || st.PerDay[3].Plays != 1 || st.PerDay[1].Plays != 0 {
+		t.Errorf("plays per day = %v", st.PerDay)
+	}
+}
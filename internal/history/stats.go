@@ -0,0 +1,118 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// A Count is the number of plays of an artist or an album.
+type Count struct {
+	Name  string
+	Plays int
+}
+
+// A Day holds plays and listening time of a single day.
+type Day struct {
+	Date    time.Time
+	Plays   int
+	Seconds int
+}
+
+// A Period holds plays and listening time over the last number of days.
+type Period struct {
+	Days    int
+	Plays   int
+	Seconds int
+}
+
+// Stats holds aggregates computed from the listening history.
+type Stats struct {
+	TopArtists []Count
+	TopAlbums  []Count
+	PerDay     []Day
+	Periods    []Period
+}
+
+// periods are the numbers of days listening time is summed up over.
+var periods = []int{7, 30, 365}
+
+// Summarize computes statistics of plays as of now. Top artists and albums
+// include up to top entries played in the last topDays days, and plays per day
+// are listed for the last days days, the most recent one first.
+func Summarize(plays []Play, now time.Time, top, topDays, days int) Stats {
+	var st Stats
+	today := startOfDay(now)
+
+	for _, d := range periods {
+		p := Period{Days: d}
+		since := today.AddDate(0, 0, -d+1)
+
+		for _, pl := range plays {
+			if !pl.Time.Before(since) {
+				p.Plays++
+				p.Seconds += pl.Seconds
+			}
+		}
+
+		st.Periods = append(st.Periods, p)
+	}
+
+	for i := 0; i < days; i++ {
+		st.PerDay = append(st.PerDay, Day{Date: today.AddDate(0, 0, -i)})
+	}
+
+	artists := map[string]int{}
+	albums := map[string]int{}
+	topSince := today.AddDate(0, 0, -topDays+1)
+
+	for _, pl := range plays {
+		if i := int(today.Sub(startOfDay(pl.Time.In(now.Location()))).Hours()/24 + 0.5); i >= 0 && i < days {
+			st.PerDay[i].Plays++
+			st.PerDay[i].Seconds += pl.Seconds
+		}
+
+		if pl.Time.Before(topSince) {
+			continue
+		}
+
+		if pl.Artist != "" {
+			artists[pl.Artist]++
+		}
+
+		if pl.Album != "" {
+			albums[pl.Artist+" - "+pl.Album]++
+		}
+	}
+
+	st.TopArtists = topCounts(artists, top)
+	st.TopAlbums = topCounts(albums, top)
+
+	return st
+}
+
+// topCounts returns up to n most played entries of m, ordered by plays and name.
+func topCounts(m map[string]int, n int) []Count {
+	var c []Count
+	for name, plays := range m {
+		c = append(c, Count{Name: name, Plays: plays})
+	}
+
+	sort.Slice(c, func(i, j int) bool {
+		if c[i].Plays != c[j].Plays {
+			return c[i].Plays > c[j].Plays
+		}
+
+		return c[i].Name < c[j].Name
+	})
+
+	if len(c) > n {
+		c = c[:n]
+	}
+
+	return c
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,165 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File is the name of a file in the state directory holding listening history.
+const File = "history.jsonl"
+
+// A Play is a single listen of a track, recorded once the listen threshold
+// was crossed. Its time is the time the track started playing.
+type Play struct {
+	Time    time.Time `json:"time"`
+	Artist  string    `json:"artist"`
+	Album   string    `json:"album,omitempty"`
+	Track   string    `json:"track"`
+	Service string    `json:"service,omitempty"`
+	// Seconds is the time the track was listened to, which grows until
+	// it ends or another one starts.
+	Seconds int `json:"seconds"`
+	// Length is track's length, unknown for streams.
+	Length int `json:"length,omitempty"`
+}
+
+// Store keeps plays in a JSON lines file, one play per line.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Append adds p to the end of the history file, creating it if needed.
+func (s *Store) Append(p Play) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// maxLine is the longest line of the history file [Store.UpdateLast] finds.
+const maxLine = 64 << 10
+
+// UpdateLast replaces the last play in the history file with p, which has to
+// be the same play, e.g. once it's known how long it was listened to.
+func (s *Store) UpdateLast(p Play) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	off := max(fi.Size()-maxLine, 0)
+	tail := make([]byte, fi.Size()-off)
+	if _, err := f.ReadAt(tail, off); err != nil {
+		return err
+	}
+
+	tail = bytes.TrimSuffix(tail, []byte("\n"))
+	start := bytes.LastIndexByte(tail, '\n') + 1
+	if start == 0 && off > 0 {
+		return errors.New("last play is too long")
+	}
+
+	var last Play
+	if err := json.Unmarshal(tail[start:], &last); err != nil {
+		return err
+	}
+
+	if !last.Time.Equal(p.Time) || last.Track != p.Track {
+		return errors.New("last play is another one: " + last.Track)
+	}
+
+	if err := f.Truncate(off + int64(start)); err != nil {
+		return err
+	}
+
+	_, err = f.WriteAt(append(data, '\n'), off+int64(start))
+	return err
+}
+
+// Load reads all plays from the history file, oldest first. A missing file
+// means nothing was played yet. Malformed lines, e.g. ones cut short by
+// a crash, are skipped.
+func (s *Store) Load() ([]Play, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+	defer f.Close()
+
+	var plays []Play
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var p Play
+		if err := json.Unmarshal(sc.Bytes(), &p); err != nil {
+			continue
+		}
+
+		plays = append(plays, p)
+	}
+
+	return plays, sc.Err()
+}
+
+// LastPlayed returns the time each artist was last played, by lowercase name.
+func (s *Store) LastPlayed() (map[string]time.Time, error) {
+	plays, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	last := map[string]time.Time{}
+	for _, p := range plays {
+		a := strings.ToLower(p.Artist)
+		if p.Time.After(last[a]) {
+			last[a] = p.Time
+		}
+	}
+
+	return last, nil
+}
@@ -1,11 +1,16 @@
 package keyboard
 
 import (
+	"strconv"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/mkozjak/blutui/internal/app"
 	"github.com/mkozjak/blutui/internal/bar"
+	"github.com/mkozjak/blutui/internal/keymap"
 	"github.com/mkozjak/blutui/internal/library"
+	"github.com/mkozjak/blutui/internal/notify"
 	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/blutui/internal/playlist"
 	"github.com/mkozjak/tview"
 )
 
@@ -16,25 +21,51 @@ type pagesManager interface {
 	SwitchToPage(name string) *tview.Pages
 }
 
+// rescanner starts a library rescan on the player, see [reindex.Monitor].
+type rescanner interface {
+	Start()
+}
+
 type GlobalHandler struct {
-	app     app.FocusStopper
-	player  player.Controller
-	library library.Command
-	pages   pagesManager
-	bar     *bar.Bar
+	app       app.FocusStopper
+	player    player.Controller
+	library   library.Command
+	rescanner rescanner
+	playlists playlist.Command
+	pages     pagesManager
+	bar       *bar.Bar
+	notifier  notify.Notifier
+	keys      *keymap.Keymap
 }
 
-func NewGlobalHandler(a app.FocusStopper, p player.Controller, l library.Command, pg pagesManager, b *bar.Bar) *GlobalHandler {
+func NewGlobalHandler(a app.FocusStopper, p player.Controller, l library.Command, r rescanner,
+	pl playlist.Command, pg pagesManager, b *bar.Bar, n notify.Notifier, k *keymap.Keymap) *GlobalHandler {
 	return &GlobalHandler{
-		app:     a,
-		player:  p,
-		library: l,
-		pages:   pg,
-		bar:     b,
+		app:       a,
+		player:    p,
+		library:   l,
+		rescanner: r,
+		playlists: pl,
+		pages:     pg,
+		bar:       b,
+		notifier:  n,
+		keys:      k,
 	}
 }
 
+// pageActions maps actions that show pages to their names.
+var pageActions = map[keymap.Action]string{
+	keymap.ShowLocal:      "local",
+	keymap.ShowTidal:      "tidal",
+	keymap.ShowPlaylists:  "playlists",
+	keymap.ShowRadio:      "radio",
+	keymap.ShowNowPlaying: "nowplaying",
+	keymap.ShowHistory:    "history",
+	keymap.ShowMessages:   notify.PageName,
+}
+
 func (h *GlobalHandler) Listen(event *tcell.EventKey) *tcell.EventKey {
+	// ctrl+q can't be rebound, so there's always a way out
 	switch event.Key() {
 	case tcell.KeyCtrlQ:
 		h.app.Stop()
@@ -44,59 +75,93 @@ func (h *GlobalHandler) Listen(event *tcell.EventKey) *tcell.EventKey {
 		return event
 	}
 
-	switch event.Rune() {
-	case '1':
-		p, _ := h.pages.GetFrontPage()
-		if p != "local" {
-			h.pages.SwitchToPage("local")
-		}
+	p, _ := h.pages.GetFrontPage()
+	a := h.keys.Action(event, keymap.Global)
 
-		return nil
-	case '2':
-		p, _ := h.pages.GetFrontPage()
-		if p != "tidal" {
-			h.pages.SwitchToPage("tidal")
+	// Library actions handled here since they need the bar
+	if a == "" && (p == "local" || p == "tidal") {
+		a = h.keys.Action(event, keymap.Library)
+	}
+
+	if n, ok := pageActions[a]; ok {
+		if p != n {
+			h.pages.SwitchToPage(n)
 		}
 
 		return nil
-	case 'p':
+	}
+
+	switch a {
+	case keymap.Playpause:
 		go h.player.Playpause()
-	case 's':
+	case keymap.Stop:
 		go h.player.Stop()
-	case '>':
+	case keymap.Next:
 		go h.player.Next()
-	case '<':
+	case keymap.Previous:
 		go h.player.Previous()
-	case '+':
-		go h.player.VolumeHold(true)
-	case '-':
-		go h.player.VolumeHold(false)
-	case 'm':
+	case keymap.VolumeUp:
+		go h.player.VolumeUp(false)
+	case keymap.VolumeDown:
+		go h.player.VolumeDown(false)
+	case keymap.VolumeUpBig:
+		go h.player.VolumeUp(true)
+	case keymap.VolumeDownBig:
+		go h.player.VolumeDown(true)
+	case keymap.SetVolume:
+		v := h.player.Status().Volume
+
+		h.bar.Prompt("volume: ", strconv.Itoa(v), func(in string) {
+			n, err := player.ParseVolume(in, v)
+			if err != nil {
+				h.notifier.Warn("invalid volume", err)
+				return
+			}
+
+			go h.player.SetVolume(n)
+		})
+
+		return nil
+	case keymap.ToggleMute:
 		go h.player.ToggleMute()
-	case 'o':
+	case keymap.JumpToPlaying:
 		if h.player.State() == "play" {
 			h.library.SelectCpArtist()
 		}
-	case 'r':
+	case keymap.ToggleRepeat:
 		go h.player.ToggleRepeatMode()
-	case 'u':
+	case keymap.UpdateLibrary:
 		go h.library.UpdateData()
-	case 'h':
-		p, _ := h.pages.GetFrontPage()
-		if p != "help" {
+	case keymap.RescanLibrary:
+		if !h.player.Online() {
+			h.notifier.Info("player is offline")
+			break
+		}
+
+		go h.rescanner.Start()
+	case keymap.SaveQueue:
+		h.bar.Prompt("save queue as: ", "", func(name string) {
+			go h.playlists.SaveQueue(name)
+		})
+
+		return nil
+	case keymap.ToggleHelp:
+		if p == "help" {
+			h.pages.HidePage("help")
+		} else {
 			h.pages.ShowPage("help")
-			return nil
 		}
-	case 'f':
-		p, _ := h.pages.GetFrontPage()
-		if p == "help" || h.library.IsFiltered() {
+
+		return nil
+	case keymap.SearchArtists:
+		if h.library.IsFiltered() {
 			return event
 		}
 
 		h.bar.Show("search")
 		h.app.SetFocus(h.bar.SearchContainer())
 		return nil
-	case 'q':
+	case keymap.Quit:
 		h.app.Stop()
 	}
 
@@ -105,29 +170,22 @@ func (h *GlobalHandler) Listen(event *tcell.EventKey) *tcell.EventKey {
 
 type HelpHandler struct {
 	pages pagesManager
+	keys  *keymap.Keymap
 }
 
-func NewHelpHandler(pg pagesManager) *HelpHandler {
+func NewHelpHandler(pg pagesManager, k *keymap.Keymap) *HelpHandler {
 	return &HelpHandler{
 		pages: pg,
+		keys:  k,
 	}
 }
 
 func (k *HelpHandler) Listen(event *tcell.EventKey) *tcell.EventKey {
-	switch event.Key() {
-	case tcell.KeyEscape:
+	switch k.keys.Action(event, keymap.Help) {
+	case keymap.CloseHelp:
 		k.pages.HidePage("help")
 		return nil
 	}
 
-	switch event.Rune() {
-	case 'h':
-		p, _ := k.pages.GetFrontPage()
-		if p == "help" {
-			k.pages.HidePage("help")
-			return nil
-		}
-	}
-
 	return event
 }
@@ -0,0 +1,156 @@
+package keymap
+
+// Scope tells where an action is available. Scopes form a tree: an action
+// is also reachable from children of its scope, so a key can't be bound
+// in a scope and in any of its ancestors.
+type Scope string
+
+const (
+	Global     Scope = "global"
+	Navigation Scope = "navigation" // lists and tables of all pages
+	Library    Scope = "library"    // local and tidal library pages
+	Artists    Scope = "artists"    // artist pane of a library
+	Album      Scope = "album"      // album track lists of a library
+	Playlists  Scope = "playlists"
+	Radio      Scope = "radio"
+	Help       Scope = "help"
+	Menu       Scope = "menu" // context menu
+)
+
+// parents holds the parent of each scope but [Global].
+var parents = map[Scope]Scope{
+	Navigation: Global,
+	Library:    Navigation,
+	Artists:    Library,
+	Album:      Library,
+	Playlists:  Navigation,
+	Radio:      Navigation,
+	Help:       Global,
+	Menu:       Navigation,
+}
+
+// Action identifies something a key can be bound to.
+type Action string
+
+const (
+	ShowLocal      Action = "page.local"
+	ShowTidal      Action = "page.tidal"
+	ShowPlaylists  Action = "page.playlists"
+	ShowRadio      Action = "page.radio"
+	ShowNowPlaying Action = "page.nowplaying"
+	ShowHistory    Action = "page.history"
+	ShowMessages   Action = "page.messages"
+	Playpause      Action = "player.playpause"
+	Stop           Action = "player.stop"
+	Next           Action = "player.next"
+	Previous       Action = "player.previous"
+	VolumeUp       Action = "player.volumeUp"
+	VolumeDown     Action = "player.volumeDown"
+	VolumeUpBig    Action = "player.volumeUpBig"
+	VolumeDownBig  Action = "player.volumeDownBig"
+	SetVolume      Action = "player.setVolume"
+	ToggleMute     Action = "player.mute"
+	ToggleRepeat   Action = "player.repeat"
+	JumpToPlaying  Action = "library.jumpToPlaying"
+	UpdateLibrary  Action = "library.update"
+	RescanLibrary  Action = "library.rescan"
+	SaveQueue      Action = "queue.save"
+	ToggleHelp     Action = "app.help"
+	Quit           Action = "app.quit"
+
+	Select       Action = "nav.select"
+	Down         Action = "nav.down"
+	Up           Action = "nav.up"
+	Top          Action = "nav.top"
+	Bottom       Action = "nav.bottom"
+	PageDown     Action = "nav.pageDown"
+	PageUp       Action = "nav.pageUp"
+	SwitchPane   Action = "nav.switchPane"
+	HalfPageDown Action = "library.halfPageDown"
+	HalfPageUp   Action = "library.halfPageUp"
+
+	SearchArtists   Action = "library.search"
+	EnqueueArtist   Action = "library.enqueueArtist"
+	OpenMenu        Action = "library.menu"
+	CycleSort       Action = "library.sort"
+	ClearSearch     Action = "artists.clearSearch"
+	PlayTrack       Action = "album.playTrack"
+	PlayTrackNext   Action = "album.playTrackNext"
+	AddTrack        Action = "album.addTrack"
+	PlayAlbum       Action = "album.play"
+	PlayAlbumNext   Action = "album.playNext"
+	AddAlbum        Action = "album.add"
+	AppendPlaylist  Action = "playlists.append"
+	RenamePlaylist  Action = "playlists.rename"
+	DeletePlaylist  Action = "playlists.delete"
+	SearchStations  Action = "radio.search"
+	ToggleFavourite Action = "radio.favourite"
+	RadioBack       Action = "radio.back"
+	CloseHelp       Action = "help.close"
+	CloseMenu       Action = "menu.close"
+)
+
+// Binding describes an action and the keys it is bound to by default.
+type Binding struct {
+	Action      Action
+	Description string
+	Keys        []string
+	Scope       Scope
+}
+
+// Actions holds all actions in the order they are listed on the help screen.
+var Actions = []Binding{
+	{ShowLocal, "show local library", []string{"1"}, Global},
+	{ShowTidal, "show tidal library", []string{"2"}, Global},
+	{ShowPlaylists, "show playlists", []string{"3"}, Global},
+	{ShowRadio, "show radio", []string{"4"}, Global},
+	{ShowNowPlaying, "show now playing", []string{"5"}, Global},
+	{ShowHistory, "show listening history", []string{"6"}, Global},
+	{ShowMessages, "show warnings and errors", []string{"7"}, Global},
+	{Select, "start playback", []string{"enter"}, Navigation},
+	{PlayTrack, "play selected song only", []string{"x"}, Album},
+	{PlayTrackNext, "play selected song next", []string{"n"}, Album},
+	{AddTrack, "add selected song to queue", []string{"e"}, Album},
+	{PlayAlbum, "play selected album now", []string{"P"}, Album},
+	{PlayAlbumNext, "play selected album next", []string{"N"}, Album},
+	{AddAlbum, "add selected album to queue", []string{"E"}, Album},
+	{EnqueueArtist, "add artist's discography to queue", []string{"A"}, Library},
+	{OpenMenu, "open context menu", []string{"."}, Library},
+	{CloseMenu, "close context menu", []string{"."}, Menu},
+	{Playpause, "play/pause", []string{"p"}, Global},
+	{Stop, "stop", []string{"s"}, Global},
+	{Next, "next song", []string{">"}, Global},
+	{Previous, "previous song", []string{"<"}, Global},
+	{VolumeUp, "volume up", []string{"+"}, Global},
+	{VolumeDown, "volume down", []string{"-"}, Global},
+	{VolumeUpBig, "volume up by a big step", []string{"]"}, Global},
+	{VolumeDownBig, "volume down by a big step", []string{"["}, Global},
+	{SetVolume, "set volume", []string{"v"}, Global},
+	{ToggleMute, "toggle mute", []string{"m"}, Global},
+	{ToggleRepeat, "toggle repeat mode (none, all, one)", []string{"r"}, Global},
+	{Down, "move down", []string{"j"}, Navigation},
+	{Up, "move up", []string{"k"}, Navigation},
+	{Top, "go to the top", []string{"g"}, Navigation},
+	{Bottom, "go to the bottom", []string{"G"}, Navigation},
+	{PageDown, "page down", []string{"ctrl+f"}, Navigation},
+	{PageUp, "page up", []string{"ctrl+b"}, Navigation},
+	{HalfPageDown, "half page down", []string{"ctrl+d"}, Library},
+	{HalfPageUp, "half page up", []string{"ctrl+u"}, Library},
+	{SwitchPane, "switch pane", []string{"tab"}, Navigation},
+	{JumpToPlaying, "jump to currently playing artist", []string{"o"}, Global},
+	{SearchArtists, "search artists", []string{"f"}, Library},
+	{CycleSort, "change order of focused pane", []string{"O"}, Library},
+	{ClearSearch, "clear artist search", []string{"esc"}, Artists},
+	{UpdateLibrary, "update library", []string{"u"}, Global},
+	{RescanLibrary, "rescan library on the player", []string{"U"}, Global},
+	{SaveQueue, "save queue as playlist", []string{"S"}, Global},
+	{AppendPlaylist, "append playlist to queue", []string{"a"}, Playlists},
+	{RenamePlaylist, "rename playlist", []string{"R"}, Playlists},
+	{DeletePlaylist, "delete playlist", []string{"D"}, Playlists},
+	{SearchStations, "search radio stations", []string{"f"}, Radio},
+	{ToggleFavourite, "toggle favourite station", []string{"F"}, Radio},
+	{RadioBack, "go back in radio", []string{"esc", "backspace"}, Radio},
+	{ToggleHelp, "show this screen", []string{"h"}, Global},
+	{CloseHelp, "close this screen", []string{"esc"}, Help},
+	{Quit, "quit app", []string{"q"}, Global},
+}
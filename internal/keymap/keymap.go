@@ -0,0 +1,192 @@
+// Package keymap binds keys to actions. Each action has default keys,
+// which can be rebound or unbound in a keymap file, and the help screen
+// is generated from the resulting bindings.
+package keymap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// File is the name of a file in the config directory that rebinds actions,
+// mapping action ids to lists of keys, e.g.
+//
+//	{
+//	  "player.playpause": ["space"],
+//	  "player.stop": []
+//	}
+//
+// An empty list unbinds the action. Actions that aren't listed keep their
+// default keys. See [ParseKey] for names of keys.
+const File = "keys.json"
+
+// Keymap holds key bindings of all actions.
+type Keymap struct {
+	keys    map[Action][]string
+	actions map[Scope]map[string]Action
+}
+
+// Default returns the keymap with default bindings.
+func Default() *Keymap {
+	k, err := New(nil)
+	if err != nil {
+		panic("conflicting default key bindings: " + err.Error())
+	}
+
+	return k
+}
+
+// New returns a keymap with default bindings replaced by those given in
+// custom. It fails on unknown actions, invalid keys and conflicting bindings.
+func New(custom map[Action][]string) (*Keymap, error) {
+	k := &Keymap{
+		keys:    map[Action][]string{},
+		actions: map[Scope]map[string]Action{},
+	}
+
+	var errs []error
+
+	for a := range custom {
+		if _, ok := find(a); !ok {
+			errs = append(errs, fmt.Errorf("unknown action %q", a))
+		}
+	}
+
+	for _, b := range Actions {
+		keys, ok := custom[b.Action]
+		if !ok {
+			keys = b.Keys
+		}
+
+		for _, key := range keys {
+			n, err := ParseKey(key)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", b.Action, err))
+				continue
+			}
+
+			if k.actions[b.Scope] == nil {
+				k.actions[b.Scope] = map[string]Action{}
+			}
+
+			if _, ok := k.actions[b.Scope][n]; !ok {
+				k.actions[b.Scope][n] = b.Action
+				k.keys[b.Action] = append(k.keys[b.Action], n)
+				continue
+			}
+
+			if k.actions[b.Scope][n] != b.Action {
+				errs = append(errs, conflict(n, k.actions[b.Scope][n], b.Action))
+			}
+		}
+	}
+
+	errs = append(errs, k.conflicts()...)
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return k, nil
+}
+
+// Load returns the keymap customized by [File] at path. A missing file
+// means default bindings are used.
+func Load(path string) (*Keymap, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Default(), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var custom map[Action][]string
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	k, err := New(custom)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return k, nil
+}
+
+// conflicts returns keys bound in a scope and in any of its ancestors,
+// since the ancestor's action would shadow the other one.
+func (k *Keymap) conflicts() []error {
+	var errs []error
+
+	for _, b := range Actions {
+		for _, key := range k.keys[b.Action] {
+			for s := parents[b.Scope]; s != ""; s = parents[s] {
+				if a, ok := k.actions[s][key]; ok {
+					errs = append(errs, conflict(key, a, b.Action))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+func conflict(key string, a, b Action) error {
+	return fmt.Errorf("key %q is bound to both %s and %s", key, a, b)
+}
+
+func find(a Action) (Binding, bool) {
+	for _, b := range Actions {
+		if b.Action == a {
+			return b, true
+		}
+	}
+
+	return Binding{}, false
+}
+
+// Action returns the action a pressed key is bound to in the first of
+// the given scopes that binds it, or an empty string.
+func (k *Keymap) Action(event *tcell.EventKey, scopes ...Scope) Action {
+	n := KeyName(event)
+	if n == "" {
+		return ""
+	}
+
+	for _, s := range scopes {
+		if a, ok := k.actions[s][n]; ok {
+			return a
+		}
+	}
+
+	return ""
+}
+
+// Keys returns keys bound to an action.
+func (k *Keymap) Keys(a Action) []string {
+	return k.keys[a]
+}
+
+// Help returns a line for each bound action, listing its keys and
+// description, in the order of [Actions].
+func (k *Keymap) Help() string {
+	var sb strings.Builder
+
+	for _, b := range Actions {
+		if len(k.keys[b.Action]) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "%s - %s\n", strings.Join(k.keys[b.Action], ", "), b.Description)
+	}
+
+	return sb.String()
+}
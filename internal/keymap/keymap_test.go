@@ -0,0 +1,132 @@
+package keymap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestKeyName(t *testing.T) {
+	tests := []struct {
+		event *tcell.EventKey
+		want  string
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'G', tcell.ModNone), "G"},
+		{tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), "space"},
+		{tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModAlt), "alt+x"},
+		{tcell.NewEventKey(tcell.KeyCtrlF, 0, tcell.ModCtrl), "ctrl+f"},
+		{tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone), "tab"},
+		{tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), "enter"},
+		{tcell.NewEventKey(tcell.KeyBackspace, 0, tcell.ModNone), "backspace"},
+		{tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone), "backspace"},
+		{tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), "esc"},
+		{tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModNone), "f5"},
+	}
+
+	for _, tt := range tests {
+		if got := KeyName(tt.event); got != tt.want {
+			t.Errorf("KeyName(%s) = %q, want %q", tt.event.Name(), got, tt.want)
+		}
+
+		// every name a key reports can be bound
+		if n, err := ParseKey(tt.want); err != nil || n != tt.want {
+			t.Errorf("ParseKey(%q) = %q, %v", tt.want, n, err)
+		}
+	}
+
+	for _, name := range []string{"ctrl+1", "hyper+x", "alt+nothing", ""} {
+		if _, err := ParseKey(name); err == nil {
+			t.Errorf("ParseKey(%q) succeeded", name)
+		}
+	}
+
+	if n, _ := ParseKey("Ctrl+I"); n != "tab" {
+		t.Errorf("ParseKey(Ctrl+I) = %q, want tab", n)
+	}
+}
+
+func TestNew(t *testing.T) {
+	k := Default()
+
+	p := tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModNone)
+	f := tcell.NewEventKey(tcell.KeyRune, 'f', tcell.ModNone)
+
+	if a := k.Action(p, Global); a != Playpause {
+		t.Errorf("p is bound to %q, want %q", a, Playpause)
+	}
+
+	if a := k.Action(f, Global, Radio); a != SearchStations {
+		t.Errorf("f is bound to %q on radio, want %q", a, SearchStations)
+	}
+
+	k, err := New(map[Action][]string{Playpause: {"space", "P"}, Stop: {}})
+	if err == nil || !strings.Contains(err.Error(), `"P" is bound to both player.playpause and album.play`) {
+		t.Errorf("got error %v, want a conflict with album.play", err)
+	}
+
+	k, err = New(map[Action][]string{Playpause: {"space"}, Stop: {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	space := tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone)
+	if a := k.Action(space, Global); a != Playpause {
+		t.Errorf("space is bound to %q, want %q", a, Playpause)
+	}
+
+	if a := k.Action(p, Global); a != "" {
+		t.Errorf("p is still bound to %q", a)
+	}
+
+	help := k.Help()
+	if !strings.Contains(help, "space - play/pause\n") || strings.Contains(help, " - stop\n") {
+		t.Errorf("unexpected help:\n%s", help)
+	}
+
+	errs := []map[Action][]string{
+		{"player.dance": {"d"}},
+		{Stop: {"hyper+s"}},
+		{Top: {"j"}},       // same scope
+		{PlayAlbum: {"p"}}, // shadowed by a global action
+	}
+
+	for _, custom := range errs {
+		if _, err := New(custom); err == nil {
+			t.Errorf("New(%v) succeeded", custom)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	k, err := Load(filepath.Join(dir, File))
+	if err != nil || len(k.Keys(Quit)) != 1 {
+		t.Fatalf("missing file: %v, quit bound to %v", err, k.Keys(Quit))
+	}
+
+	path := filepath.Join(dir, "keys.json")
+	if err := os.WriteFile(path, []byte(`{"app.quit": ["Q", "ctrl+c"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(k.Keys(Quit), " "); got != "Q ctrl+c" {
+		t.Errorf("quit bound to %q, want Q ctrl+c", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"app.quit": "q"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("malformed file loaded")
+	}
+}
@@ -0,0 +1,115 @@
+package keymap
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// namedKeys maps names of keys other than characters to tcell keys.
+var namedKeys = map[string]tcell.Key{
+	"enter":     tcell.KeyEnter,
+	"tab":       tcell.KeyTab,
+	"backtab":   tcell.KeyBacktab,
+	"esc":       tcell.KeyEscape,
+	"backspace": tcell.KeyBackspace2,
+	"delete":    tcell.KeyDelete,
+	"insert":    tcell.KeyInsert,
+	"up":        tcell.KeyUp,
+	"down":      tcell.KeyDown,
+	"left":      tcell.KeyLeft,
+	"right":     tcell.KeyRight,
+	"home":      tcell.KeyHome,
+	"end":       tcell.KeyEnd,
+	"pgup":      tcell.KeyPgUp,
+	"pgdn":      tcell.KeyPgDn,
+}
+
+// keyNames is the reverse of namedKeys.
+var keyNames = map[tcell.Key]string{}
+
+func init() {
+	for n, k := range namedKeys {
+		keyNames[k] = n
+	}
+
+	keyNames[tcell.KeyBackspace] = "backspace"
+
+	for i := 1; i <= 12; i++ {
+		n := fmt.Sprintf("f%d", i)
+		k := tcell.KeyF1 + tcell.Key(i-1)
+		namedKeys[n], keyNames[k] = k, n
+	}
+}
+
+// ParseKey validates a key name and returns it normalized. Names are
+// characters, such as "p" or "G", "space", "enter", "tab", "backtab", "esc",
+// "backspace", "delete", "insert", arrows "up", "down", "left" and "right",
+// "home", "end", "pgup", "pgdn", "f1" to "f12", and "ctrl+" followed by
+// a letter or "alt+" followed by any of the others.
+func ParseKey(name string) (string, error) {
+	if name == " " {
+		return "space", nil
+	}
+
+	if utf8.RuneCountInString(name) == 1 {
+		return name, nil
+	}
+
+	lower := strings.ToLower(name)
+
+	if l, ok := strings.CutPrefix(lower, "ctrl+"); ok {
+		if len(l) == 1 && l[0] >= 'a' && l[0] <= 'z' {
+			// terminals send these as other keys
+			if n, ok := map[string]string{"h": "backspace", "i": "tab", "m": "enter"}[l]; ok {
+				return n, nil
+			}
+
+			return lower, nil
+		}
+
+		return "", fmt.Errorf("invalid key %q: ctrl+ must be followed by a letter", name)
+	}
+
+	if strings.HasPrefix(lower, "alt+") {
+		k, err := ParseKey(name[len("alt+"):])
+		if err != nil {
+			return "", err
+		}
+
+		return "alt+" + k, nil
+	}
+
+	if _, ok := namedKeys[lower]; ok || lower == "space" {
+		return lower, nil
+	}
+
+	return "", fmt.Errorf("invalid key %q", name)
+}
+
+// KeyName returns the name of a pressed key, in the form returned by [ParseKey].
+func KeyName(event *tcell.EventKey) string {
+	var name string
+
+	switch k := event.Key(); {
+	case k == tcell.KeyRune && event.Rune() == ' ':
+		name = "space"
+	case k == tcell.KeyRune:
+		name = string(event.Rune())
+	case keyNames[k] != "":
+		// checked before control keys, since tab is ctrl+i, enter is ctrl+m, etc.
+		name = keyNames[k]
+	case k >= tcell.KeyCtrlA && k <= tcell.KeyCtrlZ:
+		return fmt.Sprintf("ctrl+%c", 'a'+rune(k-tcell.KeyCtrlA))
+	default:
+		return ""
+	}
+
+	if event.Modifiers()&tcell.ModAlt != 0 {
+		name = "alt+" + name
+	}
+
+	return name
+}
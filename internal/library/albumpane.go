@@ -2,10 +2,13 @@ package library
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/keymap"
+	"github.com/mkozjak/blutui/internal/theme"
 	"github.com/mkozjak/tview"
 )
 
@@ -16,25 +19,41 @@ func (l *Library) createAlbumContainer() *tview.Grid {
 
 	p.SetTitle(" [::b]Track ").
 		SetBorder(true).
-		SetBorderColor(tcell.ColorCornflowerBlue).
+		SetBorderColor(theme.Current.Border).
 		SetBackgroundColor(tcell.ColorDefault).
 		SetTitleAlign(tview.AlignLeft).
-		SetCustomBorders(internal.CustomBorders)
+		SetCustomBorders(internal.CustomBorders).
+		SetMouseCapture(l.albumPaneMouseHandler)
 
 	return p
 }
 
 func (l *Library) drawAlbum(artist string, album album) *tview.Table {
-	durms := internal.FormatDuration(album.duration)
+	// genre, quality and length shown along the album's title
+	var parts []string
+	for _, s := range []string{album.genre, album.quality(), internal.FormatDuration(album.duration)} {
+		if s != "" {
+			parts = append(parts, tview.Escape(s))
+		}
+	}
+
+	details := strings.Join(parts, " · ")
+	rows := album.rows()
+
+	// first row holding a track, below the heading of the first disc
+	firstRow := 0
+	if len(rows) > 0 && rows[0] < 0 {
+		firstRow = 1
+	}
 
 	// Create a new album and set it as not selectable by default
 	// so that it's first track doesn't get highlighted.
 	c := tview.NewTable().
 		SetSelectable(false, false)
 
-	c.SetTitle(fmt.Sprintf("[::b]%s (%d)", internal.EscapeStyleTag(album.name), album.year)).
+	c.SetTitle("[::b]" + internal.EscapeStyleTag(album.name) + albumYear(album)).
 		SetBorder(true).
-		SetBorderColor(tcell.ColorCornflowerBlue).
+		SetBorderColor(theme.Current.Border).
 		SetBackgroundColor(tcell.ColorDefault).
 		SetTitleAlign(tview.AlignLeft).
 		SetCustomBorders(internal.NoBorders).
@@ -54,13 +73,13 @@ func (l *Library) drawAlbum(artist string, album album) *tview.Table {
 	c.SetDrawFunc(func(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
 		centerY := y + height/c.GetRowCount()/2
 
-		for cx := x + len(c.GetTitle()) - 3; cx < x+width-len(durms)-2; cx++ {
+		for cx := x + len(c.GetTitle()) - 3; cx < x+width-tview.TaggedStringWidth(details)-2; cx++ {
 			screen.SetContent(cx, centerY, tview.BoxDrawingsLightHorizontal, nil,
-				tcell.StyleDefault.Foreground(tcell.ColorCornflowerBlue))
+				tcell.StyleDefault.Foreground(theme.Current.Border))
 		}
 
-		// Write album length along the horizontal line
-		tview.Print(screen, "[::b]"+durms, x+1, centerY, width-2, tview.AlignRight, tcell.ColorWhite)
+		// Write album details along the horizontal line
+		tview.Print(screen, "[::b]"+details, x+1, centerY, width-2, tview.AlignRight, theme.Current.Text)
 
 		// Space for other content
 		return x + 1, centerY + 1, width - 2, height - (centerY + 1 - y)
@@ -68,8 +87,8 @@ func (l *Library) drawAlbum(artist string, album album) *tview.Table {
 
 	// Set album tracklist keymap
 	c.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Rune() {
-		case 'j':
+		switch a := l.keys.Action(event, keymap.Navigation, keymap.Album); a {
+		case keymap.Down:
 			currRow, _ := c.GetSelection()
 
 			// Reached the end of current album, so skip to next one if available.
@@ -83,10 +102,10 @@ func (l *Library) drawAlbum(artist string, album album) *tview.Table {
 
 			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
 
-		case 'k':
+		case keymap.Up:
 			currRow, _ := c.GetSelection()
 
-			if currRow == 0 {
+			if currRow <= firstRow {
 				albumIndex := l.selectedAlbumIdx()
 
 				if albumIndex != 0 {
@@ -96,98 +115,205 @@ func (l *Library) drawAlbum(artist string, album album) *tview.Table {
 
 			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
 
-		case 'x':
+		case keymap.PlayTrack:
 			currRow, _ := c.GetSelection()
-			trackName := c.GetCell(currRow, 0).Text
-
-			u, _, err := l.trackURL(trackName, artist, album.name)
-			if err != nil {
-				panic(err)
+			if currRow >= len(rows) || rows[currRow] < 0 {
+				return nil
 			}
 
 			// play currently selected track only
-			go l.player.Play(u)
+			go l.player.Play(album.tracks[rows[currRow]].playUrl)
+			return nil
+
+		case keymap.PlayTrackNext, keymap.AddTrack:
+			currRow, _ := c.GetSelection()
+			if currRow >= len(rows) || rows[currRow] < 0 {
+				return nil
+			}
+
+			qa := playNext
+			if a == keymap.AddTrack {
+				qa = addLast
+			}
+
+			// queue currently selected track
+			go l.enqueueTrack(album.tracks[rows[currRow]], qa)
+			return nil
+
+		case keymap.PlayAlbum:
+			go l.enqueueAlbum(artist, album.name, playNow)
+			return nil
+
+		case keymap.PlayAlbumNext:
+			go l.enqueueAlbum(artist, album.name, playNext)
+			return nil
+
+		case keymap.AddAlbum:
+			go l.enqueueAlbum(artist, album.name, addLast)
 			return nil
 		}
 
 		return event
 	})
 
-	c.SetSelectedFunc(func(row, col int) {
-		_, autoplay, err := l.trackURL(album.tracks[row].name, artist, album.name)
-		if err != nil {
-			panic(err)
+	// play track and add subsequent album tracks to queue
+	play := func(row int) {
+		if row >= len(rows) || rows[row] < 0 {
+			return
 		}
 
-		// play track and add subsequent album tracks to queue
-		go l.player.Play(autoplay)
+		go l.player.Play(album.tracks[rows[row]].autoplayUrl)
+	}
+
+	c.SetMouseCapture(l.albumMouseHandler(c, play))
+
+	c.SetSelectedFunc(func(row, col int) {
+		play(row)
 	})
 
-	// print album tracks
-	for i, t := range album.tracks {
-		track := tview.NewTableCell("").
-			SetTextColor(tcell.ColorDefault).
-			SetSelectedStyle(tcell.Style{}.
-				Background(tcell.ColorCornflowerBlue).
-				Foreground(tcell.ColorWhite)).
+	// numbers are aligned to the widest one
+	numWidth := 0
+	for _, t := range album.tracks {
+		if t.number > 0 {
+			numWidth = max(numWidth, len(strconv.Itoa(t.number)))
+		}
+	}
+
+	// print album tracks, headed by their discs if there are more
+	for row, i := range rows {
+		if i < 0 {
+			disc := album.tracks[rows[row+1]].disc
+			heading := "[::b]Disc " + strconv.Itoa(disc)
+			if disc == 0 {
+				heading = "[::b]Other"
+			}
+
+			c.SetCell(row, 0, tview.NewTableCell("").SetSelectable(false).SetTransparency(true))
+			c.SetCell(row, 1, tview.NewTableCell(heading).
+				SetTextColor(theme.Current.Dim).
+				SetSelectable(false).
+				SetTransparency(true))
+			c.SetCell(row, 2, tview.NewTableCell("").SetSelectable(false).SetTransparency(true))
+			continue
+		}
+
+		t := album.tracks[i]
+
+		num := ""
+		if t.number > 0 {
+			num = fmt.Sprintf("%*d.", numWidth, t.number)
+		}
+
+		number := tview.NewTableCell(num).
+			SetTextColor(theme.Current.Dim).
+			SetSelectedStyle(theme.Current.SelectedStyle(true)).
+			SetAlign(tview.AlignRight).
+			SetTransparency(true).
+			SetSelectable(true)
+
+		playing := plays(album, t, l.CpTrackName, l.CpAlbumName, l.cpFile)
+
+		track := tview.NewTableCell(trackText(t, playing)).
+			SetTextColor(theme.Current.Text).
+			SetSelectedStyle(theme.Current.SelectedStyle(true)).
 			SetAlign(tview.AlignLeft).
 			SetExpansion(1).
 			SetTransparency(true).
 			SetSelectable(true)
 
-		if l.CpTrackName != "" &&
-			l.CpTrackName == internal.CleanTrackName(t.name) &&
-			l.CpAlbumName == album.name {
-			track.SetText("[yellow]" + t.name)
-		} else {
-			track.SetText(t.name)
-		}
-
 		dur := tview.NewTableCell(internal.FormatDuration(t.duration)).
-			SetTextColor(tcell.ColorDefault).
-			SetSelectedStyle(tcell.Style{}.
-				Background(tcell.ColorCornflowerBlue).
-				Foreground(tcell.ColorWhite)).
+			SetTextColor(theme.Current.Text).
+			SetSelectedStyle(theme.Current.SelectedStyle(true)).
 			SetAlign(tview.AlignRight).
 			SetExpansion(1).
 			SetTransparency(true).
 			SetSelectable(true)
 
-		c.SetCell(i, 0, track)
-		c.SetCell(i, 1, dur)
+		c.SetCell(row, 0, number)
+		c.SetCell(row, 1, track)
+		c.SetCell(row, 2, dur)
 	}
 
+	c.Select(firstRow, 0)
+
 	return c
 }
 
+// DrawInitAlbums draws albums of the first artist, if there is one.
 func (l *Library) DrawInitAlbums() {
+	if len(l.artists) == 0 {
+		return
+	}
+
 	r := l.drawArtistAlbums(l.artists[0], l.albumPane)
 	l.albumPane.SetRows(r...)
 }
 
-func (l *Library) MarkCpTrack(track, artist, album string) {
-	if l.cpArtistIdx < 0 {
-		return
+// albumYear returns the year shown after the title of album a, marked with
+// a tilde if it was only found in the path of its files.
+func albumYear(a album) string {
+	switch a.yearSource {
+	case yearFromTags:
+		return fmt.Sprintf(" (%d)", a.year)
+	case yearFromPath:
+		return fmt.Sprintf(" (~%d)", a.year)
 	}
 
-	if l.artists[l.cpArtistIdx] != artist {
+	return ""
+}
+
+// plays reports whether track t of album al is the one named name on album
+// albumName, playing from file. Tracks whose title repeats on the album, e.g.
+// on another disc, are told apart by their file if the player names it.
+func plays(al album, t track, name, albumName, file string) bool {
+	if name == "" || t.name != name || al.name != albumName {
+		return false
+	}
+
+	return file == "" || t.fn == "" || !al.repeats(name) || t.fn == file
+}
+
+// trackText returns the text of the title cell of track t, which is marked
+// if it's playing and followed by its artist if it has one of its own.
+func trackText(t track, playing bool) string {
+	text := t.name
+	if playing {
+		text = theme.Current.NowPlayingTag() + text
+	}
+
+	if t.artist != "" {
+		text += " " + theme.Current.Tag(theme.Current.Dim) + "· " + t.artist
+	}
+
+	return text
+}
+
+// MarkCpTrack highlights track on album by artist, as named by the player,
+// if the artist is selected. file tells tracks with the same title apart.
+func (l *Library) MarkCpTrack(track, artist, album, file string) {
+	l.cpFile = file
+
+	entry := l.entryOf(artist, album)
+	if l.cpArtistIdx < 0 || l.selectedArtist() != entry {
 		return
 	}
 
-	for _, al := range l.currentArtistAlbums {
-		alName := internal.CleanAlbumName(al.GetTitle())
+	albums := l.albumArtists[entry].albums
+
+	for i, c := range l.currentArtistAlbums {
+		if i >= len(albums) {
+			break
+		}
 
-		for i := 1; i < al.GetRowCount(); i++ {
-			c := al.GetCell(i, 0)
-			origTrack := c.Text
+		al := albums[i]
 
-			// Unmark track if not played currently or not from current album
-			if internal.CleanTrackName(origTrack) != track || alName != album {
-				al.SetCell(i, 0, c.SetText(strings.TrimPrefix(origTrack, "[yellow]")))
+		for row, ti := range al.rows() {
+			if ti < 0 {
 				continue
 			}
 
-			al.SetCell(i, 0, c.SetText("[yellow]"+origTrack))
+			t := al.tracks[ti]
+			c.GetCell(row, 1).SetText(trackText(t, plays(al, t, track, album, file)))
 		}
 	}
 }
@@ -197,11 +323,11 @@ func (l *Library) drawArtistAlbums(artist string, c *tview.Grid) []int {
 	l.currentArtistAlbums = nil
 
 	// remove style from the string
-	cArtist := strings.TrimPrefix(artist, "[yellow]")
+	cArtist := strings.TrimPrefix(artist, theme.Current.NowPlayingTag())
 
 	for i, album := range l.albumArtists[cArtist].albums {
 		albumTable := l.drawAlbum(cArtist, album)
-		alHeights = append(alHeights, len(album.tracks)+2)
+		alHeights = append(alHeights, len(album.rows())+2)
 
 		// automatically focus the first track from the first album
 		// since grid is the parent, it will automatically lose focus
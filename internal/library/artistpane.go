@@ -6,52 +6,60 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/theme"
 	"github.com/mkozjak/tview"
 )
 
 // left pane - artists
 func (l *Library) createArtistContainer() *tview.List {
-	artistPaneStyle := tcell.Style{}
-	artistPaneStyle.Background(tcell.ColorDefault)
+	artistPaneStyle := tcell.Style{}.Background(tcell.ColorDefault).Foreground(theme.Current.Text)
 
 	p := tview.NewList().
 		SetHighlightFullLine(true).
 		SetWrapAround(false).
-		SetSelectedTextColor(tcell.ColorWhite).
-		SetSelectedBackgroundColor(tcell.ColorCornflowerBlue).
+		SetSelectedStyle(theme.Current.SelectedStyle(true)).
 		ShowSecondaryText(false).
 		SetMainTextStyle(artistPaneStyle)
 
 	p.SetTitle(" [::b]Artist ").
 		SetBorder(true).
-		SetBorderColor(tcell.ColorCornflowerBlue).
+		SetBorderColor(theme.Current.Border).
 		SetBackgroundColor(tcell.ColorDefault).
 		SetTitleAlign(tview.AlignLeft).
 		SetCustomBorders(internal.CustomBorders).
 		// set artists list keymap
 		SetInputCapture(l.artistPaneKeyboardHandler).
+		SetMouseCapture(l.artistPaneMouseHandler).
 		SetFocusFunc(func() {
-			p.SetSelectedBackgroundColor(tcell.ColorCornflowerBlue)
+			p.SetSelectedStyle(theme.Current.SelectedStyle(true))
 		}).
 		SetBlurFunc(func() {
 			l.app.SetPrevFocused("artistpane")
-			p.SetSelectedBackgroundColor(tcell.ColorLightGray)
+			p.SetSelectedStyle(theme.Current.SelectedStyle(false))
 		})
 
 	return p
 }
 
+// FilterArtistPane redraws the artist pane so that it only holds artists
+// given in f, keeping the order they were given in, e.g. by search rank.
 func (l *Library) FilterArtistPane(f []string) {
-	for _, a := range l.artists {
-		if !slices.Contains(f, a) {
-			r := l.artistPane.FindItems(a, "", false, true)
-			l.artistPane.RemoveItem(r[0])
-		}
+	if len(f) == 0 {
+		return
 	}
 
-	if len(f) > 0 {
-		l.artistPaneFiltered = true
+	l.artistPane.Clear()
+	l.cpArtistIdx = -1
+
+	for _, a := range f {
+		if !slices.Contains(l.artists, a) {
+			continue
+		}
+
+		l.artistPane.AddItem(a, "", 0, nil)
 	}
+
+	l.artistPaneFiltered = true
 }
 
 func (l *Library) DrawArtistPane() {
@@ -73,18 +81,22 @@ func (l *Library) SelectCpArtist() {
 	l.artistPane.SetCurrentItem(l.cpArtistIdx)
 }
 
-func (l *Library) MarkCpArtist(name string) {
+// MarkCpArtist highlights the artist pane entry holding album by artist, as
+// named by the player, or clears the highlight if artist is empty.
+func (l *Library) MarkCpArtist(artist, album string) {
 	// clear previously highlighted items
 	if l.cpArtistIdx >= 0 {
 		n, _ := l.artistPane.GetItemText(l.cpArtistIdx)
-		l.artistPane.SetItemText(l.cpArtistIdx, strings.TrimPrefix(n, "[yellow]"), "")
+		l.artistPane.SetItemText(l.cpArtistIdx, strings.TrimPrefix(n, theme.Current.NowPlayingTag()), "")
 	}
 
-	if name == "" {
+	if artist == "" {
 		l.cpArtistIdx = -1
 		return
 	}
 
+	name := l.entryOf(artist, album)
+
 	// highlight artist
 	// track is highlighted through l.drawAlbum
 	idx := l.artistPane.FindItems(name, "", false, true)
@@ -93,6 +105,6 @@ func (l *Library) MarkCpArtist(name string) {
 	}
 
 	n, _ := l.artistPane.GetItemText(idx[0])
-	l.artistPane.SetItemText(idx[0], "[yellow]"+n, "")
+	l.artistPane.SetItemText(idx[0], theme.Current.NowPlayingTag()+n, "")
 	l.cpArtistIdx = idx[0]
 }
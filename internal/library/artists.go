@@ -0,0 +1,141 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+// AliasFile is the name of the file in the config directory mapping
+// variants of artist names to the name their albums are shown under, e.g.
+//
+//	{"Beatles, The": "The Beatles"}
+const AliasFile = "aliases.json"
+
+// Compilations is the artist pane entry holding albums by various artists.
+const Compilations = "Compilations"
+
+// Aliases maps variants of artist names, by [artistKey], to the names shown
+// instead. A nil Aliases resolves every name to itself.
+type Aliases map[string]string
+
+// LoadAliases reads artist aliases from the [AliasFile] at path. A missing
+// file means no aliases.
+func LoadAliases(path string) (Aliases, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Aliases{}, nil
+		}
+
+		return nil, err
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	a := make(Aliases, len(raw))
+	for from, to := range raw {
+		if strings.TrimSpace(to) == "" {
+			return nil, fmt.Errorf("alias of %q is empty", from)
+		}
+
+		a[artistKey(from)] = strings.TrimSpace(to)
+	}
+
+	return a, nil
+}
+
+// resolve returns the name albums of artist name are shown under.
+func (a Aliases) resolve(name string) string {
+	if to, ok := a[artistKey(name)]; ok {
+		return to
+	}
+
+	return name
+}
+
+// artistKey returns the key albums are grouped by, which is the same for
+// names differing only in case or spacing, such as "AC/DC" and "ac/dc ".
+func artistKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// variousArtists are keys of album artists of compilations.
+var variousArtists = []string{"various artists", "various", "va", "v.a.", "v/a"}
+
+// isCompilation reports whether an album by artist with tracks is a
+// compilation: either its album artist says so, or most tracks are by at
+// least three artists of their own. Track artists the same as the album's
+// are expected to be cleared, as done by [applySongs].
+func isCompilation(artist string, tracks []track) bool {
+	if slices.Contains(variousArtists, artistKey(artist)) {
+		return true
+	}
+
+	var own int
+	seen := map[string]bool{}
+
+	for _, t := range tracks {
+		if t.artist == "" {
+			continue
+		}
+
+		own++
+		seen[artistKey(t.artist)] = true
+	}
+
+	return len(seen) >= 3 && own*2 > len(tracks)
+}
+
+// groupArtist returns the artist pane entry album al, listed by artist, is
+// shown under. names holds entries by [artistKey] and gains new ones, so
+// variants of a name share the entry of the first one seen.
+func (l *Library) groupArtist(artist string, al album, names map[string]string) string {
+	if al.compilation {
+		return Compilations
+	}
+
+	name := l.aliases.resolve(strings.TrimSpace(artist))
+	key := artistKey(name)
+
+	if n, ok := names[key]; ok {
+		return n
+	}
+
+	names[key] = name
+	return name
+}
+
+// entryOf returns the artist pane entry holding album name by artist as the
+// player names them, which differs for aliased artists and compilations.
+// If name is empty, only the artist is matched.
+func (l *Library) entryOf(artist, name string) string {
+	key := artistKey(l.aliases.resolve(artist))
+
+	hasAlbum := func(ar string) bool {
+		return slices.ContainsFunc(l.albumArtists[ar].albums, func(al album) bool {
+			return al.name == name
+		})
+	}
+
+	entry := artist
+	for ar := range l.albumArtists {
+		if artistKey(ar) == key {
+			entry = ar
+			break
+		}
+	}
+
+	if name != "" && !hasAlbum(entry) && hasAlbum(Compilations) {
+		return Compilations
+	}
+
+	return entry
+}
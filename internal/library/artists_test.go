@@ -0,0 +1,129 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadAliases(t *testing.T) {
+	dir := t.TempDir()
+
+	a, err := LoadAliases(filepath.Join(dir, AliasFile))
+	if err != nil || len(a) != 0 {
+		t.Fatalf("without a file got %v, %v", a, err)
+	}
+
+	path := filepath.Join(dir, AliasFile)
+	if err := os.WriteFile(path, []byte(`{"Beatles, The": "The Beatles"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if a, err = LoadAliases(path); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Beatles, The", "beatles,  the"} {
+		if got := a.resolve(name); got != "The Beatles" {
+			t.Errorf("%q resolved to %q", name, got)
+		}
+	}
+
+	if got := a.resolve("dEUS"); got != "dEUS" {
+		t.Errorf("dEUS resolved to %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"Beatles, The": " "}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadAliases(path); err == nil {
+		t.Error("an empty alias was accepted")
+	}
+}
+
+func TestIsCompilation(t *testing.T) {
+	tests := []struct {
+		artist string
+		tracks []track
+		want   bool
+	}{
+		{"Various Artists", nil, true},
+		{"VA", []track{{}}, true},
+		{"Low", []track{{}, {}, {}}, false},
+		{"Low", []track{{artist: "Dirty Three"}, {}, {}}, false},
+		{"Trojan", []track{{artist: "Toots"}, {artist: "Desmond Dekker"}, {artist: "Toots"}, {}}, false},
+		{"Trojan", []track{{artist: "Toots"}, {artist: "Desmond Dekker"}, {artist: "The Upsetters"}, {}}, true},
+	}
+
+	for _, tt := range tests {
+		if got := isCompilation(tt.artist, tt.tracks); got != tt.want {
+			t.Errorf("%s %+v: got %v", tt.artist, tt.tracks, got)
+		}
+	}
+}
+
+func TestLoadGroupsArtists(t *testing.T) {
+	f := &fakeLibrary{
+		albums: []item{
+			{Text: "Back in Black", Text2: "AC/DC", BrowseKey: "bb"},
+			{Text: "Powerage", Text2: "ac/dc ", BrowseKey: "pw"},
+			{Text: "Pocket Revolution", Text2: "dEUS", BrowseKey: "pr"},
+			{Text: "Abbey Road", Text2: "Beatles, The", BrowseKey: "ar"},
+			{Text: "Revolver", Text2: "the beatles", BrowseKey: "rv"},
+			{Text: "Pulp Fiction", Text2: "Various Artists", BrowseKey: "pf"},
+		},
+		fetched: map[string]int{},
+	}
+
+	l := &Library{
+		API:     "http://player",
+		service: "local",
+		aliases: Aliases{artistKey("Beatles, The"): "The Beatles"},
+	}
+
+	artists, err := l.load(f.fetch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"AC/DC":       {"Back in Black", "Powerage"},
+		"dEUS":        {"Pocket Revolution"},
+		"The Beatles": {"Abbey Road", "Revolver"},
+		Compilations:  {"Pulp Fiction"},
+	}
+
+	if len(artists) != len(want) {
+		t.Errorf("got artists %v", sortArtists(artists, ByName, nil))
+	}
+
+	for name, albums := range want {
+		var got []string
+		for _, al := range artists[name].albums {
+			got = append(got, al.name)
+		}
+
+		slices.Sort(got)
+		if !slices.Equal(got, albums) {
+			t.Errorf("%s: got %q, want %q", name, got, albums)
+		}
+	}
+
+	// the player names artists of tracks, which are matched to entries
+	l.albumArtists = artists
+
+	tests := []struct{ artist, album, want string }{
+		{"ac/dc", "Powerage", "AC/DC"},
+		{"Beatles, The", "", "The Beatles"},
+		{"Urge Overkill", "Pulp Fiction", Compilations},
+		{"Nobody", "", "Nobody"},
+	}
+
+	for _, tt := range tests {
+		if got := l.entryOf(tt.artist, tt.album); got != tt.want {
+			t.Errorf("entry of %s - %s: got %q, want %q", tt.artist, tt.album, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package library
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/keymap"
+	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/blutui/internal/theme"
+	"github.com/mkozjak/tview"
+)
+
+// menuEntry is a single action shown in a context menu popup.
+// It either holds an item fetched from the player's context menu or
+// a local action, such as opening another menu.
+type menuEntry struct {
+	text  string
+	item  item
+	local func()
+}
+
+// menuTarget describes the library item a context menu was opened for.
+type menuTarget struct {
+	artist string
+	album  string
+	track  string
+}
+
+// Width and maximum height of a context menu popup.
+const (
+	menuWidth     = 44
+	menuMaxHeight = 20
+)
+
+// OpenContextMenu opens a context menu for the currently focused library item,
+// which is either the selected artist or the selected track of an album.
+func (l *Library) OpenContextMenu() {
+	if l.artistPane.HasFocus() {
+		l.openArtistMenu(l.selectedArtist())
+		return
+	}
+
+	i := l.selectedAlbumIdx()
+	if i < 0 {
+		return
+	}
+
+	t := l.currentArtistAlbums[i]
+	row, _ := t.GetSelection()
+	artist := l.selectedArtist()
+	al := l.albumArtists[artist].albums[i]
+
+	rows := al.rows()
+	if row < 0 || row >= len(rows) || rows[row] < 0 {
+		return
+	}
+
+	tr := al.tracks[rows[row]]
+	l.openTrackMenu(menuTarget{artist: artist, album: al.name, track: tr.name}, tr)
+}
+
+// selectedArtist returns the name of the artist selected in the artist pane.
+func (l *Library) selectedArtist() string {
+	if l.artistPane.GetItemCount() == 0 {
+		return ""
+	}
+
+	n, _ := l.artistPane.GetItemText(l.artistPane.GetCurrentItem())
+	return strings.TrimPrefix(n, theme.Current.NowPlayingTag())
+}
+
+// SelectArtist selects an artist in the artist pane given its name and
+// focuses the pane. Filtered results are cleared if the artist is not among them.
+func (l *Library) SelectArtist(name string) bool {
+	find := func() int {
+		for i := 0; i < l.artistPane.GetItemCount(); i++ {
+			n, _ := l.artistPane.GetItemText(i)
+			if strings.TrimPrefix(n, theme.Current.NowPlayingTag()) == name {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	i := find()
+	if i < 0 && l.artistPaneFiltered {
+		l.DrawArtistPane()
+		l.artistPaneFiltered = false
+		i = find()
+	}
+
+	if i < 0 {
+		return false
+	}
+
+	l.artistPane.SetCurrentItem(i)
+	l.app.SetFocus(l.artistPane)
+	return true
+}
+
+func (l *Library) openArtistMenu(artist string) {
+	if artist == "" {
+		return
+	}
+
+	l.showMenu(artist, []menuEntry{
+		{text: "Add discography to queue", local: func() {
+			go l.enqueueArtist(artist)
+		}},
+		{text: "Play discography next", local: func() {
+			go l.enqueueArtistNext(artist)
+		}},
+	}, menuTarget{artist: artist})
+}
+
+func (l *Library) openTrackMenu(t menuTarget, tr track) {
+	if player.ReportOffline(l.player, l.notifier) {
+		return
+	}
+
+	go func() {
+		items, err := l.contextMenu(tr.contextMenuKey)
+		if err != nil {
+			l.notifier.Error("fetching track context menu", err, "track", t.track)
+			return
+		}
+
+		entries := entriesFromItems(items)
+		entries = append(entries,
+			menuEntry{text: "Album actions…", local: func() { l.openAlbumMenu(t) }},
+			menuEntry{text: "Artist actions…", local: func() { l.openArtistMenu(t.artist) }})
+
+		l.app.QueueUpdateDraw(func() {
+			l.showMenu(t.track, entries, t)
+		})
+	}()
+}
+
+func (l *Library) openAlbumMenu(t menuTarget) {
+	var key string
+	for _, al := range l.albumArtists[t.artist].albums {
+		if al.name == t.album {
+			key = al.contextMenuKey
+			break
+		}
+	}
+
+	l.openRemoteMenu(t.album, key, t)
+}
+
+// openRemoteMenu fetches a menu from the player given its key, that is either
+// a context menu key or a browse key of a submenu, and shows it.
+func (l *Library) openRemoteMenu(title, key string, t menuTarget) {
+	if player.ReportOffline(l.player, l.notifier) {
+		return
+	}
+
+	go func() {
+		items, err := l.contextMenu(key)
+		if err != nil {
+			l.notifier.Error("fetching context menu", err, "title", title)
+			return
+		}
+
+		l.app.QueueUpdateDraw(func() {
+			l.showMenu(title, entriesFromItems(items), t)
+		})
+	}()
+}
+
+func entriesFromItems(items []item) []menuEntry {
+	var e []menuEntry
+
+	for _, it := range items {
+		if it.Text == "" {
+			continue
+		}
+
+		e = append(e, menuEntry{text: it.Text, item: it})
+	}
+
+	return e
+}
+
+// showMenu renders entries as a navigable list in a popup titled title.
+func (l *Library) showMenu(title string, entries []menuEntry, t menuTarget) {
+	if len(entries) == 0 {
+		l.notifier.Info("no actions available for " + title)
+		return
+	}
+
+	m := tview.NewList().
+		SetHighlightFullLine(true).
+		SetWrapAround(false).
+		ShowSecondaryText(false).
+		SetSelectedStyle(theme.Current.SelectedStyle(true)).
+		SetMainTextStyle(tcell.StyleDefault)
+
+	m.SetTitle(" [::b]" + internal.EscapeStyleTag(title) + " ").
+		SetBorder(true).
+		SetBorderColor(theme.Current.Border).
+		SetBackgroundColor(tcell.ColorDefault).
+		SetTitleAlign(tview.AlignLeft).
+		SetCustomBorders(internal.CustomBorders)
+
+	for _, e := range entries {
+		m.AddItem(internal.EscapeStyleTag(e.text), "", 0, nil)
+	}
+
+	m.SetSelectedFunc(func(i int, _ string, _ string, _ rune) {
+		l.app.HidePopup()
+		l.runMenuEntry(entries[i], t)
+	})
+
+	m.SetDoneFunc(func() {
+		l.app.HidePopup()
+	})
+
+	m.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch l.keys.Action(event, keymap.Navigation, keymap.Menu) {
+		case keymap.Select:
+			return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+		case keymap.Down:
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case keymap.Up:
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		case keymap.Top:
+			return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+		case keymap.Bottom:
+			return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+		case keymap.CloseMenu:
+			l.app.HidePopup()
+			return nil
+		}
+
+		return event
+	})
+
+	l.app.ShowPopup(m, menuWidth, min(len(entries), menuMaxHeight-2)+2)
+}
+
+// runMenuEntry executes a selected context menu entry. Local entries are
+// simply called. Entries pointing to the current artist are resolved locally
+// by selecting the artist, other browsable entries open a submenu, and the
+// rest are executed on the player, after which the affected view is refreshed.
+func (l *Library) runMenuEntry(e menuEntry, t menuTarget) {
+	if e.local != nil {
+		e.local()
+		return
+	}
+
+	it := e.item
+
+	switch {
+	case it.BrowseKey != "" && isArtistLink(it.BrowseKey) && t.artist != "":
+		if !l.SelectArtist(t.artist) {
+			l.notifier.Info("artist not in library: " + t.artist)
+		}
+	case it.BrowseKey != "":
+		l.openRemoteMenu(it.Text, it.BrowseKey, t)
+	case it.ActionURL != "":
+		go func() {
+			l.player.Play(it.ActionURL)
+			l.notifier.Info(it.Text + ": done")
+			l.refreshAfter(it.ActionURL)
+		}()
+	case it.PlayURL != "":
+		go func() {
+			l.player.Play(it.PlayURL)
+			l.notifier.Info(it.Text + ": done")
+		}()
+	case it.AutoplayURL != "":
+		go l.player.Play(it.AutoplayURL)
+	}
+}
+
+// isArtistLink reports whether a context menu entry given its browse key
+// leads to an artist page, which either lists albums by an artist, as in
+// "LocalMusic:/Albums?service=LocalMusic&artist=Low", or is an artist of
+// a streaming service, as in "Tidal:artist/3521920".
+func isArtistLink(key string) bool {
+	_, rest, _ := strings.Cut(key, ":")
+	if u, err := url.QueryUnescape(rest); err == nil {
+		rest = u
+	}
+
+	path, query, _ := strings.Cut(rest, "?")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for _, s := range segments {
+		if strings.Contains(strings.ToLower(s), "radio") {
+			return false
+		}
+	}
+
+	q, err := url.ParseQuery(query)
+	if err == nil && q.Has("artist") && !q.Has("album") {
+		return true
+	}
+
+	return len(segments) >= 2 && strings.EqualFold(segments[len(segments)-2], "artist")
+}
+
+// refreshAfter refreshes the library if an action given its url may have
+// changed its contents. For example, Tidal library consists of favourites,
+// so adding or removing a favourite changes it.
+func (l *Library) refreshAfter(action string) {
+	u, err := url.Parse(action)
+	if err != nil {
+		return
+	}
+
+	if l.service == "tidal" && strings.Contains(strings.ToLower(u.Path), "favourite") {
+		l.UpdateData()
+	}
+}
@@ -2,11 +2,13 @@ package library
 
 import (
 	"github.com/gdamore/tcell/v2"
+	"github.com/mkozjak/blutui/internal/keymap"
+	"github.com/mkozjak/tview"
 )
 
 func (l *Library) KeyboardHandler(event *tcell.EventKey) *tcell.EventKey {
-	switch event.Key() {
-	case tcell.KeyTab:
+	switch l.keys.Action(event, keymap.Navigation, keymap.Library) {
+	case keymap.SwitchPane:
 		if l.artistPane.HasFocus() {
 			// Set first artist's album as selectable and make it focused
 			l.currentArtistAlbums[0].SetSelectable(true, false)
@@ -18,16 +20,18 @@ func (l *Library) KeyboardHandler(event *tcell.EventKey) *tcell.EventKey {
 		}
 
 		return nil
-	case tcell.KeyCtrlB:
+	case keymap.Select:
+		return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+	case keymap.PageUp:
 		return tcell.NewEventKey(tcell.KeyPgUp, 0, tcell.ModNone)
-	case tcell.KeyCtrlF:
+	case keymap.PageDown:
 		return tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone)
-	case tcell.KeyCtrlD:
+	case keymap.HalfPageDown:
 		if l.artistPane.HasFocus() == true {
 			l.artistPane.SetCurrentItem(l.artistPane.GetCurrentItem() + 20)
 			return nil
 		}
-	case tcell.KeyCtrlU:
+	case keymap.HalfPageUp:
 		if l.artistPane.HasFocus() == true {
 			i := l.artistPane.GetCurrentItem()
 			if i < 20 {
@@ -38,40 +42,118 @@ func (l *Library) KeyboardHandler(event *tcell.EventKey) *tcell.EventKey {
 
 			return nil
 		}
-	}
-
-	switch event.Rune() {
-	case 'g':
+	case keymap.Top:
 		if l.artistPane.HasFocus() {
 			l.artistPane.SetCurrentItem(0)
 		}
 
 		return nil
-	case 'G':
+	case keymap.Bottom:
 		if l.artistPane.HasFocus() {
 			l.artistPane.SetCurrentItem(-1)
 		}
 
+		return nil
+	case keymap.EnqueueArtist:
+		if l.artistPane.GetItemCount() == 0 {
+			return nil
+		}
+
+		// add whole discography of the selected artist to queue
+		go l.enqueueArtist(l.selectedArtist())
+
+		return nil
+	case keymap.OpenMenu:
+		l.OpenContextMenu()
+		return nil
+	case keymap.CycleSort:
+		l.cycleSort()
 		return nil
 	}
 
 	return event
 }
 
+// artistPaneMouseHandler opens a context menu for an artist on right click.
+func (l *Library) artistPaneMouseHandler(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	if action != tview.MouseRightClick || !l.artistPane.InRect(event.Position()) {
+		return action, event
+	}
+
+	_, y := event.Position()
+	_, top, _, _ := l.artistPane.GetInnerRect()
+	offset, _ := l.artistPane.GetOffset()
+
+	i := y - top + offset
+	if i < 0 || i >= l.artistPane.GetItemCount() {
+		return action, nil
+	}
+
+	l.artistPane.SetCurrentItem(i)
+	l.app.SetFocus(l.artistPane)
+	l.OpenContextMenu()
+
+	return action, nil
+}
+
+// albumMouseHandler returns a handler that opens a context menu for
+// a track of album table c on right click and plays it using play
+// on double click.
+func (l *Library) albumMouseHandler(c *tview.Table, play func(row int)) func(tview.MouseAction, *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	return func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if action != tview.MouseRightClick && action != tview.MouseLeftDoubleClick ||
+			!c.InRect(event.Position()) {
+			return action, event
+		}
+
+		row, _ := c.CellAt(event.Position())
+		if row < 0 || row >= c.GetRowCount() {
+			return action, nil
+		}
+
+		l.app.SetFocus(c)
+		c.Select(row, 0)
+
+		if action == tview.MouseLeftDoubleClick {
+			play(row)
+		} else {
+			l.OpenContextMenu()
+		}
+
+		return tview.MouseConsumed, nil
+	}
+}
+
+// albumPaneMouseHandler scrolls the album pane by one album per wheel step,
+// leaving the focus where it is.
+func (l *Library) albumPaneMouseHandler(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	if action != tview.MouseScrollUp && action != tview.MouseScrollDown ||
+		!l.albumPane.InRect(event.Position()) {
+		return action, event
+	}
+
+	row, col := l.albumPane.GetOffset()
+
+	if action == tview.MouseScrollUp {
+		l.albumPane.SetOffset(max(row-1, 0), col)
+	} else {
+		l.albumPane.SetOffset(row+1, col)
+	}
+
+	return tview.MouseConsumed, nil
+}
+
 func (l *Library) artistPaneKeyboardHandler(event *tcell.EventKey) *tcell.EventKey {
-	switch event.Key() {
-	case tcell.KeyEscape:
+	switch l.keys.Action(event, keymap.Navigation, keymap.Artists) {
+	case keymap.ClearSearch:
 		if l.artistPaneFiltered {
 			l.DrawArtistPane()
 			l.artistPaneFiltered = false
 			return nil
 		}
-	}
-
-	switch event.Rune() {
-	case 'j':
+	case keymap.Down:
 		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
-	case 'k':
+	case keymap.Up:
 		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
 	}
 
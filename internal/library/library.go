@@ -3,14 +3,23 @@ package library
 import (
 	"encoding/xml"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/url"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mkozjak/blutui/cache"
 	internal "github.com/mkozjak/blutui/internal"
 	"github.com/mkozjak/blutui/internal/app"
+	"github.com/mkozjak/blutui/internal/config"
+	"github.com/mkozjak/blutui/internal/keymap"
+	"github.com/mkozjak/blutui/internal/notify"
 	"github.com/mkozjak/blutui/internal/player"
 	"github.com/mkozjak/blutui/spinner"
 	"github.com/mkozjak/tview"
@@ -37,6 +46,7 @@ type item struct {
 	Type           string `xml:"type,attr"`
 	PlayURL        string `xml:"playURL,attr"`
 	AutoplayURL    string `xml:"autoplayURL,attr"`
+	ActionURL      string `xml:"actionURL,attr"`
 	ContextMenuKey string `xml:"contextMenuKey,attr"`
 	Duration       string `xml:"duration,attr"`
 	Image          string `xml:"image,attr"`
@@ -45,29 +55,54 @@ type item struct {
 
 // Used for parsing data from /Songs
 type songs struct {
-	Song []struct {
-		Date string `xml:"date"`
-		Fn   string `xml:"fn"`
-	} `xml:"song"`
+	Song []song `xml:"song"`
+}
+
+type song struct {
+	Title      string `xml:"title"`
+	Artist     string `xml:"art"`
+	Composer   string `xml:"composer"`
+	Genre      string `xml:"genre"`
+	Date       string `xml:"date"`
+	Fn         string `xml:"fn"`
+	Disc       string `xml:"disc"`
+	Track      string `xml:"track"`
+	Format     string `xml:"format"`
+	BitDepth   string `xml:"bitDepth"`
+	SampleRate string `xml:"sampleRate"`
 }
 
 type track struct {
-	name        string
-	duration    int
-	disc        int
-	number      int
-	playUrl     string
-	autoplayUrl string
+	name           string
+	artist         string // set if it differs from the album's artist
+	composer       string
+	duration       int
+	disc           int    // 0 if unknown
+	number         int    // 0 if unknown
+	fn             string // file of a local track
+	playUrl        string
+	autoplayUrl    string
+	contextMenuKey string
 }
 
 type album struct {
-	name        string
-	year        int
-	duration    int
-	genre       string
-	tracks      []track
-	playUrl     string
-	autoplayUrl string
+	name string
+	metadata
+	duration       int
+	tracks         []track
+	playUrl        string
+	autoplayUrl    string
+	contextMenuKey string
+
+	// Listing entry the album was fetched from, which tells whether it
+	// changed when the library is refreshed
+	source item
+
+	// When the album was first seen in the library
+	firstSeen time.Time
+
+	// Whether the album is by various artists, shown under [Compilations]
+	compilation bool
 }
 
 type artist struct {
@@ -89,12 +124,18 @@ type ArtistFilter interface {
 }
 
 type CPMarkSetter interface {
-	MarkCpArtist(name string)
-	MarkCpTrack(track, artist, album string)
+	MarkCpArtist(artist, album string)
+	MarkCpTrack(track, artist, album, file string)
 	SetCpAlbumName(name string)
 	SetCpTrackName(name string)
 }
 
+type appManager interface {
+	app.Focuser
+	app.PopupShower
+	app.Updater
+}
+
 type FetchDone struct {
 	Service string
 	Error   error
@@ -102,9 +143,13 @@ type FetchDone struct {
 
 type Library struct {
 	container *tview.Flex
-	app       app.Focuser
+	app       appManager
 	player    player.Controller
 	spinner   spinner.StartStopper
+	notifier  notify.Notifier
+	history   PlayHistory
+	aliases   Aliases
+	keys      *keymap.Keymap
 	API       string
 	service   string
 
@@ -118,25 +163,110 @@ type Library struct {
 	cpArtistIdx         int
 	CpAlbumName         string
 	CpTrackName         string
+	cpFile              string // file of the track playing, if local
+
+	// Whether UpdateData is running
+	updating atomic.Bool
+
+	// Orders of artists and albums, read by fetches while changed by keys,
+	// and the path of the file keeping them
+	sortMutex sync.Mutex
+	sort      Sort
+	sortPath  string
 }
 
-func New(api, service string, a app.Focuser, p player.Controller, sp spinner.StartStopper) *Library {
-	return &Library{
+// New returns a new [Library] of service, local or tidal, given player's API
+// address, its dependencies, history of plays artists can be sorted by and
+// aliases of artist names. Orders last chosen for the library are restored
+// from the [SortFile].
+func New(api, service string, a appManager, p player.Controller, sp spinner.StartStopper, n notify.Notifier,
+	ph PlayHistory, al Aliases, k *keymap.Keymap) *Library {
+	l := &Library{
 		app:                a,
+		keys:               k,
 		player:             p,
 		spinner:            sp,
+		notifier:           n,
+		history:            ph,
+		aliases:            al,
 		API:                api,
 		service:            service,
 		albumArtists:       map[string]artist{},
 		cpArtistIdx:        -1,
 		artistPaneFiltered: false,
+		sort:               Sort{Artists: ByName, Albums: ByYear},
 	}
+
+	path, err := config.StatePath(SortFile)
+	if err != nil {
+		slog.Warn("locating sort orders", "err", err)
+		return l
+	}
+
+	l.sortPath = path
+
+	if l.sort, err = loadSort(path, service); err != nil {
+		slog.Warn("loading sort orders", "err", err, "service", service)
+	}
+
+	return l
 }
 
 func (l *Library) Artists() []string {
 	return l.artists
 }
 
+// AlbumInfo describes an album of an artist in the library.
+type AlbumInfo struct {
+	Name   string   `json:"name"`
+	Year   int      `json:"year"`
+	Tracks []string `json:"tracks"`
+
+	// YearSource tells whether the year came from "tags" or the file "path".
+	YearSource string   `json:"yearSource,omitempty"`
+	Released   string   `json:"released,omitempty"`
+	Genre      string   `json:"genre,omitempty"`
+	Composers  []string `json:"composers,omitempty"` // of its tracks, in their order
+	Format     string   `json:"format,omitempty"`
+	BitDepth   int      `json:"bitDepth,omitempty"`
+	SampleRate int      `json:"sampleRate,omitempty"`
+	FirstSeen  string   `json:"firstSeen,omitempty"` // e.g. 2024-05-01
+}
+
+// Albums returns albums of an artist in the order they are shown.
+func (l *Library) Albums(artist string) []AlbumInfo {
+	var res []AlbumInfo
+
+	for _, al := range l.albumArtists[artist].albums {
+		ai := AlbumInfo{
+			Name:       al.name,
+			Year:       al.year,
+			YearSource: al.yearSource.String(),
+			Released:   al.released,
+			Genre:      al.genre,
+			Format:     al.format,
+			BitDepth:   al.bitDepth,
+			SampleRate: al.sampleRate,
+		}
+
+		if !al.firstSeen.IsZero() {
+			ai.FirstSeen = al.firstSeen.Format(time.DateOnly)
+		}
+
+		for _, t := range al.tracks {
+			ai.Tracks = append(ai.Tracks, t.name)
+
+			if t.composer != "" && !slices.Contains(ai.Composers, t.composer) {
+				ai.Composers = append(ai.Composers, t.composer)
+			}
+		}
+
+		res = append(res, ai)
+	}
+
+	return res
+}
+
 func (l *Library) CreateContainer() *tview.Flex {
 	l.artistPane = l.createArtistContainer()
 	l.DrawArtistPane()
@@ -153,225 +283,352 @@ func (l *Library) CreateContainer() *tview.Flex {
 	return flex
 }
 
+// fetcher returns the body of the response to a request to url.
+type fetcher func(url string) ([]byte, error)
+
 func (l *Library) FetchData(cached bool, doneCh chan<- FetchDone) {
 	go l.spinner.Start()
+	defer l.spinner.Stop()
 
 	c, err := cache.LoadCache()
 	if err != nil {
-		internal.Log("Error loading local cache:", err)
+		slog.Error("loading local cache", "err", err)
 		doneCh <- FetchDone{Error: err}
 		return
 	}
 
+	// only cached responses can be used while the player is offline
+	fetch := func(url string) ([]byte, error) {
+		if !l.player.Online() {
+			return cache.Cached(url, c)
+		}
+
+		return cache.FetchAndCache(url, c, cached)
+	}
+
+	artists, err := l.load(fetch, nil)
+	if err != nil && !errors.Is(err, errNotCached) {
+		doneCh <- FetchDone{Error: err}
+		return
+	}
+
+	s := l.sorting()
+	l.settle(artists, s.Albums)
+
+	l.albumArtists = artists
+	l.artists = l.orderArtists(artists, s.Artists)
+
+	// albums missing from the cache are left for the player to be back
+	doneCh <- FetchDone{Error: err}
+}
+
+// ShowFetched draws the library once fetching it is done, as told by msg,
+// starting with albums of the first artist. A library that failed to load
+// before anything was fetched is left as it is.
+func (l *Library) ShowFetched(msg FetchDone) {
+	l.app.QueueUpdateDraw(func() {
+		if msg.Error != nil && len(l.artists) == 0 {
+			return
+		}
+
+		l.DrawArtistPane()
+		l.DrawInitAlbums()
+	})
+}
+
+// errNotCached is returned by [Library.load], along with the albums that were
+// loaded, if others are missing from the cache while the player is offline.
+var errNotCached = errors.New("albums are missing from the cache")
+
+// load returns the library's albums grouped by album artist, with aliases
+// resolved and compilations kept apart.
+// Albums found in known, by browse key, are reused as long as their listing
+// entry is the same, while others are fetched along with their tracks.
+func (l *Library) load(fetch fetcher, known map[string]album) (map[string]artist, error) {
+	listing, err := l.listAlbums(fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	artists := make(map[string]artist)
+
+	// entries by artist key, so that alias targets are shown as written
+	names := make(map[string]string)
+	for _, to := range l.aliases {
+		names[artistKey(to)] = to
+	}
+
+	var missing int
+
+	for _, it := range listing {
+		al, ok := known[it.BrowseKey]
+		if !ok || al.source != it {
+			al, err = l.fetchAlbum(fetch, it)
+			if errors.Is(err, cache.ErrNotCached) {
+				missing++
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		arName := l.groupArtist(it.Text2, al, names)
+		ar := artists[arName]
+		ar.albums = append(ar.albums, al)
+		artists[arName] = ar
+	}
+
+	if missing > 0 {
+		return artists, fmt.Errorf("%d of %d %w", missing, len(listing), errNotCached)
+	}
+
+	return artists, nil
+}
+
+// listAlbums returns entries of all albums in the library, going through
+// the alphabetical sections of the local one.
+func (l *Library) listAlbums(fetch fetcher) ([]item, error) {
 	var albums browse
 
-	fetchAlbums := func(url string) {
-		body, err := cache.FetchAndCache(url, c, cached)
+	fetchAlbums := func(url string) error {
+		body, err := fetch(url)
 		if err != nil {
-			internal.Log("Error fetching album sections:", err)
-			doneCh <- FetchDone{Error: err}
-			return
+			slog.Error("fetching album sections", "err", err)
+			return err
 		}
 
 		err = xml.Unmarshal(body, &albums)
 		if err != nil {
-			internal.Log("Error parsing the albums XML:", err, url)
-			doneCh <- FetchDone{Error: err}
-			return
+			slog.Error("parsing the albums XML", "err", err, "url", url)
 		}
-	}
 
-	l.albumArtists = make(map[string]artist)
+		return err
+	}
 
 	if l.service == "local" {
-		body, err := cache.FetchAndCache(l.API+localRootEndpoint, c, cached)
+		body, err := fetch(l.API + localRootEndpoint)
 		if err != nil {
-			internal.Log("Error fetching/caching data:", err)
-			doneCh <- FetchDone{Error: err}
-			return
+			slog.Error("fetching/caching data", "err", err)
+			return nil, err
 		}
 
 		var sections browse
 		err = xml.Unmarshal(body, &sections)
 		if err != nil {
-			internal.Log("Error parsing the sections XML:", err, string(body))
-			doneCh <- FetchDone{Error: err}
-			return
+			slog.Error("parsing the sections XML", "err", err, "body", string(body))
+			return nil, err
 		}
 
 		// parse album sections (alphabetical order) from xml
 		for _, item := range sections.Items {
-			fetchAlbums(l.API + "/Browse?key=" + url.QueryEscape(item.BrowseKey))
+			if err := fetchAlbums(l.API + "/Browse?key=" + url.QueryEscape(item.BrowseKey)); err != nil {
+				return nil, err
+			}
 		}
 	} else if l.service == "tidal" {
-		fetchAlbums(l.API + tidalRootEndpoint)
+		if err := fetchAlbums(l.API + tidalRootEndpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	return albums.Items, nil
+}
+
+// fetchAlbum returns the album listed as al with its tracks and year.
+func (l *Library) fetchAlbum(fetch fetcher, al item) (album, error) {
+	var duration int
+
+	// fetch album tracks
+	body, err := fetch(l.API + "/Browse?key=" + url.QueryEscape(al.BrowseKey))
+	if err != nil {
+		slog.Error("fetching album tracks", "err", err)
+		return album{}, err
 	}
 
-	// iterate albums and fill l.albumArtists
-	for _, al := range albums.Items {
-		var duration int
+	var tracks browse
 
-		// fetch album tracks
-		body, err := cache.FetchAndCache(l.API+"/Browse?key="+url.QueryEscape(al.BrowseKey), c, cached)
-		if err != nil {
-			internal.Log("Error fetching album tracks:", err)
-			doneCh <- FetchDone{Error: err}
-			return
-		}
+	err = xml.Unmarshal(body, &tracks)
+	if err != nil {
+		slog.Error("parsing the album tracks XML", "err", err, "body", string(body))
+		return album{}, err
+	}
 
-		var tracks browse
+	var albumTracks []track
+	for _, tr := range tracks.Items {
+		number, name := internal.SplitTrackNumber(tr.Text)
+
+		track := track{
+			name:           name,
+			artist:         tr.Text2,
+			number:         number,
+			playUrl:        tr.PlayURL,
+			autoplayUrl:    tr.AutoplayURL,
+			contextMenuKey: tr.ContextMenuKey,
+			duration: func() int {
+				l, err := strconv.Atoi(tr.Duration)
+				if err != nil {
+					return 0
+				}
 
-		err = xml.Unmarshal(body, &tracks)
-		if err != nil {
-			internal.Log("Error parsing the album tracks XML:", err, "body:", string(body))
-			doneCh <- FetchDone{Error: err}
-			return
+				return l
+			}(),
 		}
 
-		var albumTracks []track
-		for _, tr := range tracks.Items {
-			track := track{
-				name:        tr.Text,
-				playUrl:     tr.PlayURL,
-				autoplayUrl: tr.AutoplayURL,
-				duration: func() int {
-					l, err := strconv.Atoi(tr.Duration)
-					if err != nil {
-						return 0
-					}
-
-					return l
-				}(),
-			}
+		albumTracks = append(albumTracks, track)
+		duration += track.duration
+	}
 
-			albumTracks = append(albumTracks, track)
-			duration += track.duration
-		}
+	// fetch album metadata from /Songs
+	alEsc := url.QueryEscape(al.Text)
+	arEsc := url.QueryEscape(al.Text2)
+
+	body, err = fetch(l.API + "/Songs?service=LocalMusic&album=" + alEsc + "&artist=" + arEsc)
+	if err != nil {
+		slog.Error("fetching album songs", "err", err)
+		return album{}, err
+	}
 
-		arName := internal.Caser(al.Text2)
+	var s songs
 
-		// fetch album date from /Songs
-		alEsc := url.QueryEscape(al.Text)
-		arEsc := url.QueryEscape(al.Text2)
+	err = xml.Unmarshal(body, &s)
+	if err != nil {
+		slog.Error("parsing the album songs XML", "err", err, "body", string(body))
+		return album{}, err
+	}
 
-		body, err = cache.FetchAndCache(l.API+"/Songs?service=LocalMusic&album="+alEsc+"&artist="+arEsc, c, cached)
-		if err != nil {
-			internal.Log("Error fetching album date:", err)
-			doneCh <- FetchDone{Error: err}
-			return
-		}
+	var meta metadata
+	if len(s.Song) > 0 {
+		meta = metadataOf(s.Song[0])
+	}
 
-		var s songs
-		var year int
+	if meta.yearSource == yearUnknown {
+		slog.Debug("album's year not found", "album", al.Text)
+	}
 
-		err = xml.Unmarshal(body, &s)
-		if err != nil {
-			internal.Log("Error parsing the album songs XML:", err, "body:", string(body))
-			doneCh <- FetchDone{Error: err}
-			return
-		}
+	applySongs(albumTracks, s.Song, al.Text2)
+
+	return album{
+		name:           al.Text,
+		metadata:       meta,
+		tracks:         albumTracks,
+		playUrl:        al.PlayURL,
+		autoplayUrl:    al.AutoplayURL,
+		contextMenuKey: al.ContextMenuKey,
+		duration:       duration,
+		source:         al,
+		compilation:    isCompilation(al.Text2, albumTracks),
+	}, nil
+}
 
-		if len(s.Song) > 0 {
-			d := s.Song[0].Date
+func (l *Library) IsFiltered() bool {
+	return l.artistPaneFiltered
+}
 
-			if d != "" && d != "0" {
-				year, err = internal.ExtractAlbumYear(d)
-				if err != nil {
-					internal.Log("Error extracting album's year:", err)
-				}
+// applySongs fills discs, numbers and artists of tracks of an album by artist
+// from its songs, matched by title in order, and sorts tracks by disc and
+// number if they are all known and unique. Artists the same as the album's
+// are cleared.
+func applySongs(tracks []track, s []song, artist string) {
+	used := make([]bool, len(s))
+
+	for i := range tracks {
+		t := &tracks[i]
+
+		for j, sg := range s {
+			if used[j] || sg.Title != t.name {
+				continue
+			}
+
+			used[j] = true
+			t.fn = sg.Fn
+
+			if d, err := strconv.Atoi(sg.Disc); err == nil {
+				t.disc = d
 			} else {
-				year, err = internal.HackAlbumYear(s.Song[0].Fn)
-				if err != nil {
-					year, err = internal.ExtractYearFromPath(s.Song[0].Fn)
-					if err != nil {
-						internal.Log("Error extracting album's year from path:", err)
-					}
-				}
+				t.disc = internal.DiscFromPath(sg.Fn)
 			}
-		}
 
-		ar, ok := l.albumArtists[arName]
-
-		if ok {
-			ar.albums = append(ar.albums, album{
-				name:        al.Text,
-				tracks:      albumTracks,
-				year:        year,
-				playUrl:     al.PlayURL,
-				autoplayUrl: al.AutoplayURL,
-				duration:    duration,
-			})
-
-			l.albumArtists[arName] = ar
-		} else {
-			l.albumArtists[arName] = artist{
-				albums: []album{{
-					name:        al.Text,
-					tracks:      albumTracks,
-					year:        year,
-					playUrl:     al.PlayURL,
-					autoplayUrl: al.AutoplayURL,
-					duration:    duration,
-				}},
+			if n, err := strconv.Atoi(sg.Track); err == nil {
+				t.number = n
 			}
-		}
-	}
 
-	l.artists = sortArtists(l.albumArtists)
+			if sg.Artist != "" {
+				t.artist = sg.Artist
+			}
 
-	// Iterate over sorted artist names
-	for _, artistName := range l.artists {
-		ar := l.albumArtists[artistName]
+			t.composer = sg.Composer
 
-		// Sort albums by year
-		sort.Slice(ar.albums, func(i, j int) bool {
-			return ar.albums[i].year < ar.albums[j].year
-		})
+			break
+		}
 
-		l.albumArtists[artistName] = ar
+		if strings.EqualFold(t.artist, artist) {
+			t.artist = ""
+		}
 	}
 
-	l.spinner.Stop()
-	doneCh <- FetchDone{Error: nil}
-}
+	// numbers restarting on discs that aren't known can't be told apart,
+	// so the listing order is kept for them
+	type position struct{ disc, number int }
+	seen := make(map[position]bool, len(tracks))
 
-func (l *Library) IsFiltered() bool {
-	return l.artistPaneFiltered
-}
+	for _, t := range tracks {
+		pos := position{t.disc, t.number}
+		if t.number == 0 || seen[pos] {
+			return
+		}
 
-func (l *Library) UpdateData() {
-	ch := make(chan FetchDone)
-	go l.FetchData(false, ch)
+		seen[pos] = true
+	}
 
-	for {
-		msg := <-ch
-		if msg.Error != nil {
-			// TODO: show error on bar
-			panic("failed fetching initial data: " + msg.Error.Error())
+	sort.SliceStable(tracks, func(i, j int) bool {
+		if tracks[i].disc != tracks[j].disc {
+			return tracks[i].disc < tracks[j].disc
 		}
 
-		// Refresh artist pane
-		l.DrawArtistPane()
-		l.app.SetFocus(l.artistPane)
+		return tracks[i].number < tracks[j].number
+	})
+}
+
+// repeats reports whether more than one track of album a is titled name.
+func (a album) repeats(name string) bool {
+	var n int
+	for _, t := range a.tracks {
+		if t.name == name {
+			n++
+		}
 	}
+
+	return n > 1
 }
 
-func (l *Library) trackURL(name, artist, album string) (string, string, error) {
-	for _, a := range l.albumArtists[artist].albums {
-		if a.name != album {
-			continue
+// discs reports whether album a spans more than one disc.
+func (a album) discs() bool {
+	for _, t := range a.tracks {
+		if t.disc != a.tracks[0].disc {
+			return true
 		}
+	}
 
-		for _, t := range a.tracks {
-			if t.name != name {
-				continue
-			}
+	return false
+}
 
-			return t.playUrl, t.autoplayUrl, nil
+// rows returns the index of the track shown on each row of album a's table,
+// or -1 for rows heading discs, which are shown if a spans more than one.
+func (a album) rows() []int {
+	var rows []int
+	multi := a.discs()
+
+	for i, t := range a.tracks {
+		if multi && (i == 0 || t.disc != a.tracks[i-1].disc) {
+			rows = append(rows, -1)
 		}
+
+		rows = append(rows, i)
 	}
 
-	return "", "", errors.New("no such track")
+	return rows
 }
 
 func (l *Library) SetCpAlbumName(name string) {
@@ -381,18 +638,3 @@ func (l *Library) SetCpAlbumName(name string) {
 func (l *Library) SetCpTrackName(name string) {
 	l.CpTrackName = name
 }
-
-func sortArtists(input map[string]artist) []string {
-	// Iterate over the map keys and sort them alphabetically
-	names := make([]string, 0, len(input))
-
-	for n := range input {
-		names = append(names, n)
-	}
-
-	sort.Slice(names, func(i, j int) bool {
-		return strings.ToLower(names[i]) < strings.ToLower(names[j])
-	})
-
-	return names
-}
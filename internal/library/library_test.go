@@ -0,0 +1,196 @@
+package library
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mkozjak/blutui/internal/keymap"
+	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/blutui/internal/theme"
+	"github.com/mkozjak/tview"
+)
+
+func TestApplySongs(t *testing.T) {
+	// a box set listed out of order, with discs told by song and by path
+	tracks := []track{
+		{name: "Sunflower", number: 1},
+		{name: "Intro"},
+		{name: "Intro"},
+		{name: "Canada", number: 2},
+	}
+
+	s := []song{
+		{Title: "Intro", Track: "1", Fn: "/music/Low/Box/CD2/01 Intro.flac"},
+		{Title: "Canada", Disc: "1", Track: "2", Artist: "low"},
+		{Title: "Intro", Track: "1", Disc: "1", Artist: "Dirty Three"},
+		{Title: "Sunflower", Disc: "2", Track: "2"},
+	}
+
+	applySongs(tracks, s, "Low")
+
+	var got []string
+	for _, t := range tracks {
+		got = append(got, t.name+"/"+t.artist)
+	}
+
+	// titles are matched in order, so the first Intro is the one on CD2
+	want := []string{"Intro/Dirty Three", "Canada/", "Intro/", "Sunflower/"}
+	if !slices.Equal(got, want) {
+		t.Errorf("tracks %q, want %q", got, want)
+	}
+
+	al := album{tracks: tracks}
+	if rows := al.rows(); !slices.Equal(rows, []int{-1, 0, 1, -1, 2, 3}) {
+		t.Errorf("rows %v", rows)
+	}
+
+	// without all numbers, the listing order is kept
+	tracks = []track{{name: "B"}, {name: "A", number: 1}}
+	applySongs(tracks, nil, "Low")
+
+	if tracks[0].name != "B" {
+		t.Errorf("tracks %+v were sorted", tracks)
+	}
+
+	if rows := (album{tracks: tracks}).rows(); !slices.Equal(rows, []int{0, 1}) {
+		t.Errorf("rows of a single disc %v", rows)
+	}
+
+	// a box set numbered per disc, without discs in tags or paths
+	tracks = []track{{name: "A1", number: 1}, {name: "A2", number: 2}, {name: "B1", number: 1}, {name: "B2", number: 2}}
+	applySongs(tracks, nil, "Low")
+
+	got = nil
+	for _, t := range tracks {
+		got = append(got, t.name)
+	}
+
+	if want := []string{"A1", "A2", "B1", "B2"}; !slices.Equal(got, want) {
+		t.Errorf("tracks of unknown discs %q, want %q", got, want)
+	}
+}
+
+func TestClassifyAction(t *testing.T) {
+	tests := []struct {
+		url  string
+		want queueAction
+	}{
+		{"/Add?service=LocalMusic&playnow=1&album=Blue", playNow},
+		{"/Add?service=LocalMusic&where=nextAlbum&album=Blue", playNext},
+		{"/Add?service=LocalMusic&where=last&album=Blue", addLast},
+		{"/Add?service=LocalMusic&album=Weekend", -1},
+		{"/Play?url=Unknown", -1},
+	}
+
+	for _, tt := range tests {
+		if got := classifyAction(tt.url); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestIsArtistLink(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"LocalMusic:/Albums?service=LocalMusic&artist=Low", true},
+		{"LocalMusic:bySection/%2FAlbums%3Fservice%3DLocalMusic%26artist%3DLow", true},
+		{"Tidal:artist/3521920", true},
+		{"LocalMusic:/Songs?service=LocalMusic&album=Blue&artist=Joni+Mitchell", false},
+		{"Tidal:album/77646197", false},
+		{"Tidal:radio/artist/3521920", false},
+		{"LocalMusic:/Albums?service=LocalMusic&genre=Artistic", false},
+	}
+
+	for _, tt := range tests {
+		if got := isArtistLink(tt.key); got != tt.want {
+			t.Errorf("%s: got %v", tt.key, got)
+		}
+	}
+}
+
+func TestShowFetchedFailure(t *testing.T) {
+	l := &Library{app: &fakeApp{}, albumArtists: map[string]artist{}, cpArtistIdx: -1}
+	l.CreateContainer()
+
+	// nothing was loaded, e.g. the player is offline without a cache
+	l.ShowFetched(FetchDone{Error: errors.New("connection refused")})
+
+	if n := l.artistPane.GetItemCount(); n != 0 {
+		t.Fatalf("shown %d artists of an empty library", n)
+	}
+
+	// what was loaded before the failure is shown
+	l.albumArtists["Low"] = artist{albums: []album{{name: "Hey What", tracks: []track{{name: "White Horses"}}}}}
+	l.artists = []string{"Low"}
+	l.ShowFetched(FetchDone{Error: errors.New("connection refused")})
+
+	if n := l.artistPane.GetItemCount(); n != 1 {
+		t.Errorf("shown %d artists, want Low", n)
+	}
+}
+
+// fakePlayer sends URLs it's told to play to played.
+type fakePlayer struct {
+	player.Controller
+	played chan string
+}
+
+func (f *fakePlayer) Play(url string) { f.played <- url }
+func (f *fakePlayer) Online() bool    { return true }
+
+func TestRepeatedTitles(t *testing.T) {
+	box := album{name: "Box", tracks: []track{
+		{name: "Intro", disc: 1, number: 1, fn: "/music/Low/Box/CD1/01 Intro.flac",
+			playUrl: "/Play?cd1", autoplayUrl: "/Autoplay?cd1"},
+		{name: "Canada", disc: 1, number: 2, fn: "/music/Low/Box/CD1/02 Canada.flac"},
+		{name: "Intro", disc: 2, number: 1, fn: "/music/Low/Box/CD2/01 Intro.flac",
+			playUrl: "/Play?cd2", autoplayUrl: "/Autoplay?cd2"},
+	}}
+
+	fa := &fakeApp{}
+	fp := &fakePlayer{played: make(chan string, 1)}
+	l := &Library{app: fa, player: fp, keys: keymap.Default(),
+		albumArtists: map[string]artist{"Low": {albums: []album{box}}}, artists: []string{"Low"}, cpArtistIdx: -1}
+	l.CreateContainer()
+	l.DrawArtistPane()
+	l.DrawInitAlbums()
+
+	// rows are the heading of disc 1, its two tracks and the heading of disc 2
+	c := l.currentArtistAlbums[0]
+	fa.SetFocus(c)
+	c.Select(4, 0)
+
+	keys := map[string]*tcell.EventKey{
+		"/Play?cd2":     tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+		"/Autoplay?cd2": tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone),
+	}
+
+	for want, ev := range keys {
+		c.InputHandler()(ev, func(tview.Primitive) {})
+
+		select {
+		case got := <-fp.played:
+			if got != want {
+				t.Errorf("played %s, want %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("nothing played, want %s", want)
+		}
+	}
+
+	l.MarkCpArtist("Low", "Box")
+	l.MarkCpTrack("Intro", "Low", "Box", "/music/Low/Box/CD2/01 Intro.flac")
+
+	for row, want := range map[int]bool{1: false, 4: true} {
+		text := c.GetCell(row, 1).Text
+		if got := strings.HasPrefix(text, theme.Current.NowPlayingTag()); got != want {
+			t.Errorf("row %d %q marked %v, want %v", row, text, got, want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/config"
+)
+
+// yearSource tells where the year of an album came from.
+type yearSource int
+
+const (
+	yearUnknown yearSource = iota
+	yearFromTags
+	yearFromPath
+)
+
+func (s yearSource) String() string {
+	switch s {
+	case yearFromTags:
+		return "tags"
+	case yearFromPath:
+		return "path"
+	}
+
+	return ""
+}
+
+// metadata describes an album as told by the tags of its first song.
+type metadata struct {
+	genre      string
+	format     string // file format, e.g. FLAC
+	bitDepth   int
+	sampleRate int    // in Hz
+	released   string // release date as tagged, e.g. 1994-03-22 or 1994
+	year       int
+	yearSource yearSource
+}
+
+// metadataOf returns album metadata given the first song of an album. The
+// year is taken from its release date, or its path if it isn't tagged,
+// e.g. "/music/Low/Secret Name (1999)/01 Starfire.flac".
+func metadataOf(s song) metadata {
+	m := metadata{
+		genre:      s.Genre,
+		format:     strings.ToUpper(s.Format),
+		bitDepth:   atoi(s.BitDepth),
+		sampleRate: atoi(s.SampleRate),
+	}
+
+	if m.format == "" {
+		m.format = strings.ToUpper(strings.TrimPrefix(filepath.Ext(s.Fn), "."))
+	}
+
+	if d := s.Date; d != "" && d != "0" {
+		if y, err := internal.ExtractAlbumYear(d); err == nil {
+			m.released, m.year, m.yearSource = d, y, yearFromTags
+			return m
+		}
+	}
+
+	if y, err := internal.ExtractYearFromPath(s.Fn); err == nil {
+		m.year, m.yearSource = y, yearFromPath
+	}
+
+	return m
+}
+
+// atoi returns the number in s, or 0 if there's none.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+// quality returns the format, bit depth and sample rate of m in short,
+// e.g. "FLAC 24/96".
+func (m metadata) quality() string {
+	q := m.format
+	if m.bitDepth > 0 && m.sampleRate > 0 {
+		rate := strconv.FormatFloat(float64(m.sampleRate)/1000, 'f', -1, 64)
+		q = strings.TrimSpace(q + " " + strconv.Itoa(m.bitDepth) + "/" + rate)
+	}
+
+	return q
+}
+
+// SeenFile is the name of the file in the state directory recording when
+// albums were first seen in a library.
+const SeenFile = "seen.json"
+
+// seenMu guards the [SeenFile], which is shared by libraries.
+var seenMu sync.Mutex
+
+// markSeen sets the time albums of artists were first seen in the library of
+// service, recording now for those that weren't seen before.
+func markSeen(path, service string, artists map[string]artist, now time.Time) error {
+	seenMu.Lock()
+	defer seenMu.Unlock()
+
+	// first seen times by service and "artist - album"
+	seen := map[string]map[string]time.Time{}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &seen); err != nil {
+			return err
+		}
+	}
+
+	if seen[service] == nil {
+		seen[service] = map[string]time.Time{}
+	}
+
+	changed := false
+
+	for name, ar := range artists {
+		for i, al := range ar.albums {
+			key := name + " - " + al.name
+
+			t, ok := seen[service][key]
+			if !ok {
+				t = now
+				seen[service][key] = t
+				changed = true
+			}
+
+			ar.albums[i].firstSeen = t
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	data, err = json.Marshal(seen)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// seenPath returns the path of the [SeenFile].
+func seenPath() (string, error) {
+	return config.StatePath(SeenFile)
+}
+
+// settle records when albums of freshly loaded artists were first seen and
+// sorts them in order s.
+func (l *Library) settle(artists map[string]artist, s AlbumSort) {
+	if path, err := seenPath(); err != nil {
+		slog.Warn("locating seen albums", "err", err)
+	} else if err := markSeen(path, l.service, artists, time.Now()); err != nil {
+		slog.Warn("recording when albums were first seen", "err", err)
+	}
+
+	for _, ar := range artists {
+		sortAlbums(ar.albums, s)
+	}
+}
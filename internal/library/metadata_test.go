@@ -0,0 +1,87 @@
+package library
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMetadataOf(t *testing.T) {
+	tests := []struct {
+		song    song
+		year    int
+		source  yearSource
+		quality string
+	}{
+		{song{Date: "1999-03-22", Format: "flac", BitDepth: "24", SampleRate: "96000"}, 1999, yearFromTags, "FLAC 24/96"},
+		{song{Date: "0", Fn: "/music/Low/Secret Name (1999)/01 Starfire.flac"}, 1999, yearFromPath, "FLAC"},
+		{song{Date: "someday", Fn: "/music/Low/Secret Name/01 Starfire.mp3", SampleRate: "44100"}, 0, yearUnknown, "MP3"},
+		{song{Format: "FLAC", BitDepth: "16", SampleRate: "44100"}, 0, yearUnknown, "FLAC 16/44.1"},
+	}
+
+	for _, tt := range tests {
+		m := metadataOf(tt.song)
+		if m.year != tt.year || m.yearSource != tt.source || m.quality() != tt.quality {
+			t.Errorf("%+v: got %d from %q, %q", tt.song, m.year, m.yearSource, m.quality())
+		}
+	}
+}
+
+func TestComposers(t *testing.T) {
+	tracks := []track{{name: "Summertime"}, {name: "Bess, You Is My Woman"}, {name: "It Ain't Necessarily So"}}
+	s := []song{
+		{Title: "It Ain't Necessarily So", Composer: "George Gershwin"},
+		{Title: "Summertime", Composer: "George Gershwin"},
+		{Title: "Bess, You Is My Woman", Composer: "Ira Gershwin"},
+	}
+
+	applySongs(tracks, s, "Miles Davis")
+
+	if tracks[0].composer != "George Gershwin" || tracks[1].composer != "Ira Gershwin" {
+		t.Errorf("got composers %q and %q", tracks[0].composer, tracks[1].composer)
+	}
+
+	l := &Library{albumArtists: map[string]artist{
+		"Miles Davis": {albums: []album{{name: "Porgy and Bess", tracks: tracks}}},
+	}}
+
+	info := l.Albums("Miles Davis")
+	if len(info) != 1 || !slices.Equal(info[0].Composers, []string{"George Gershwin", "Ira Gershwin"}) {
+		t.Errorf("got album info %+v", info)
+	}
+}
+
+func TestMarkSeen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SeenFile)
+
+	first := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	artists := map[string]artist{"Low": {albums: []album{{name: "Secret Name"}}}}
+
+	if err := markSeen(path, "local", artists, first); err != nil {
+		t.Fatal(err)
+	}
+
+	// a later fetch keeps the time of the first one for known albums
+	later := first.AddDate(0, 1, 0)
+	artists = map[string]artist{"Low": {albums: []album{{name: "Secret Name"}, {name: "Hey What"}}}}
+
+	if err := markSeen(path, "local", artists, later); err != nil {
+		t.Fatal(err)
+	}
+
+	albums := artists["Low"].albums
+	if !albums[0].firstSeen.Equal(first) || !albums[1].firstSeen.Equal(later) {
+		t.Errorf("first seen %v and %v", albums[0].firstSeen, albums[1].firstSeen)
+	}
+
+	// albums are recorded per service
+	artists = map[string]artist{"Low": {albums: []album{{name: "Secret Name"}}}}
+	if err := markSeen(path, "tidal", artists, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if !artists["Low"].albums[0].firstSeen.Equal(later) {
+		t.Errorf("first seen on tidal %v", artists["Low"].albums[0].firstSeen)
+	}
+}
@@ -0,0 +1,223 @@
+package library
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+// queueAction is a queue-building action offered by a context menu
+// of a track or an album.
+type queueAction int
+
+const (
+	playNow queueAction = iota
+	playNext
+	addLast
+)
+
+func (q queueAction) String() string {
+	switch q {
+	case playNow:
+		return "playing now"
+	case playNext:
+		return "playing next"
+	}
+
+	return "added to queue"
+}
+
+// contextMenu fetches the context menu of a library item given its contextMenuKey.
+// Context menus are not cached since the actions they offer depend on the
+// current player state.
+func (l *Library) contextMenu(key string) ([]item, error) {
+	if key == "" {
+		return nil, errors.New("item has no context menu")
+	}
+
+	resp, err := http.Get(l.API + "/Browse?key=" + url.QueryEscape(key))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var menu browse
+
+	err = xml.Unmarshal(body, &menu)
+	if err != nil {
+		return nil, err
+	}
+
+	return menu.Items, nil
+}
+
+// actionURL returns the url of an item that performs the queue action a.
+// Actions are recognized by their /Add endpoint parameters only, as names
+// of items differ between player versions and languages.
+func actionURL(menu []item, a queueAction) (string, bool) {
+	for _, it := range menu {
+		u := it.ActionURL
+		if u == "" {
+			u = it.PlayURL
+		}
+
+		if u == "" {
+			continue
+		}
+
+		if classifyAction(u) == a {
+			return u, true
+		}
+	}
+
+	return "", false
+}
+
+// classifyAction returns the queue action performed by url u, or -1 if it
+// isn't an /Add request telling where to add.
+func classifyAction(u string) queueAction {
+	pu, err := url.Parse(u)
+	if err != nil || !strings.HasSuffix(pu.Path, "/Add") {
+		return -1
+	}
+
+	q := pu.Query()
+
+	switch {
+	case q.Get("playnow") == "1":
+		return playNow
+	case strings.HasPrefix(q.Get("where"), "next"):
+		return playNext
+	case q.Get("where") == "last":
+		return addLast
+	}
+
+	return -1
+}
+
+// enqueue performs the queue action a using the context menu given its key.
+func (l *Library) enqueue(key string, a queueAction) error {
+	menu, err := l.contextMenu(key)
+	if err != nil {
+		return err
+	}
+
+	u, ok := actionURL(menu, a)
+	if !ok {
+		return errors.New("action not offered: " + a.String())
+	}
+
+	l.player.Play(u)
+	return nil
+}
+
+// enqueueTrack performs the queue action a on track t and confirms it on
+// the status bar.
+func (l *Library) enqueueTrack(t track, a queueAction) {
+	if player.ReportOffline(l.player, l.notifier) {
+		return
+	}
+
+	if err := l.enqueue(t.contextMenuKey, a); err != nil {
+		l.notifier.Error("enqueueing track", err, "track", t.name)
+		return
+	}
+
+	l.notifier.Info(a.String() + ": " + t.name)
+}
+
+// enqueueAlbum performs the queue action a on a whole album given its name
+// and artist, and confirms it on the status bar.
+func (l *Library) enqueueAlbum(artist, album string, a queueAction) {
+	if player.ReportOffline(l.player, l.notifier) {
+		return
+	}
+
+	for _, al := range l.albumArtists[artist].albums {
+		if al.name != album {
+			continue
+		}
+
+		if err := l.enqueue(al.contextMenuKey, a); err != nil {
+			l.notifier.Error("enqueueing album", err, "album", album)
+			return
+		}
+
+		l.notifier.Info(a.String() + ": " + album)
+		return
+	}
+}
+
+// enqueueArtist adds all albums of an artist to the end of the queue
+// in the order they are shown, and confirms it on the status bar.
+func (l *Library) enqueueArtist(artist string) {
+	if player.ReportOffline(l.player, l.notifier) {
+		return
+	}
+
+	var n int
+
+	for _, al := range l.albumArtists[artist].albums {
+		if err := l.enqueue(al.contextMenuKey, addLast); err != nil {
+			l.notifier.Warn("enqueueing album", err, "album", al.name)
+			continue
+		}
+
+		n++
+	}
+
+	l.notifier.Info(fmt.Sprintf("added %d of %d albums by %s to queue", n, len(l.albumArtists[artist].albums), artist))
+}
+
+// enqueueArtistNext adds all albums of an artist right after the currently
+// playing track, keeping the order they are shown in, and confirms it on the status bar.
+func (l *Library) enqueueArtistNext(artist string) {
+	if player.ReportOffline(l.player, l.notifier) {
+		return
+	}
+
+	var n int
+	albums := l.albumArtists[artist].albums
+
+	// every album is put right after the current track, so go from the last one
+	for i := len(albums) - 1; i >= 0; i-- {
+		if err := l.enqueue(albums[i].contextMenuKey, playNext); err != nil {
+			l.notifier.Warn("enqueueing album", err, "album", albums[i].name)
+			continue
+		}
+
+		n++
+	}
+
+	l.notifier.Info(fmt.Sprintf("playing next %d of %d albums by %s", n, len(albums), artist))
+}
+
+// QueueAlbum plays an album given its name and artist now, next or last,
+// depending on where, which is "now", "next" or "last". The album is
+// enqueued in the background and confirmed on the status bar.
+func (l *Library) QueueAlbum(artist, album, where string) error {
+	a, ok := map[string]queueAction{"now": playNow, "next": playNext, "last": addLast}[where]
+	if !ok {
+		return fmt.Errorf("unknown queue position %q", where)
+	}
+
+	for _, al := range l.albumArtists[artist].albums {
+		if al.name == album {
+			go l.enqueueAlbum(artist, album, a)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no album %q by %q", album, artist)
+}
@@ -0,0 +1,268 @@
+package library
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mkozjak/blutui/cache"
+	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/blutui/internal/theme"
+)
+
+// UpdateData refreshes the library from the player. Only the album listing
+// is fetched in full, while tracks are fetched for new or changed albums
+// alone. Once done, the selection is kept and a summary of changes is shown.
+func (l *Library) UpdateData() {
+	if player.ReportOffline(l.player, l.notifier) {
+		return
+	}
+
+	// another update is already running
+	if !l.updating.CompareAndSwap(false, true) {
+		return
+	}
+	defer l.updating.Store(false)
+
+	go l.spinner.Start()
+	defer l.spinner.Stop()
+
+	c, err := cache.LoadCache()
+	if err != nil {
+		l.notifier.Error("updating library", err, "service", l.service)
+		return
+	}
+
+	// fresh responses are cached for the next start
+	fetch := func(url string) ([]byte, error) {
+		return cache.FetchAndCache(url, c, false)
+	}
+
+	known := map[string]album{}
+	for _, ar := range l.albumArtists {
+		for _, al := range ar.albums {
+			known[al.source.BrowseKey] = al
+		}
+	}
+
+	artists, err := l.load(fetch, known)
+	if err != nil {
+		l.notifier.Error("updating library", err, "service", l.service)
+		return
+	}
+
+	s := l.sorting()
+	l.settle(artists, s.Albums)
+	order := l.orderArtists(artists, s.Artists)
+
+	ch := diff(l.albumArtists, artists)
+	slog.Info("library updated", "service", l.service,
+		"addedAlbums", ch.addedAlbums, "removedAlbums", ch.removedAlbums,
+		"changedAlbums", ch.changedAlbums,
+		"addedArtists", ch.addedArtists, "removedArtists", ch.removedArtists)
+
+	l.app.QueueUpdateDraw(func() {
+		l.replace(artists, order)
+	})
+
+	l.notifier.Info(ch.String())
+}
+
+// replace swaps the library's artists for the given ones, shown in order,
+// and redraws the panes, keeping the selected artist, album and track and
+// scroll positions where they still exist.
+func (l *Library) replace(artists map[string]artist, order []string) {
+	var selected, playing string
+
+	current := l.artistPane.GetCurrentItem()
+	if l.artistPane.GetItemCount() > 0 {
+		selected, _ = l.artistPane.GetItemText(current)
+		selected = strings.TrimPrefix(selected, theme.Current.NowPlayingTag())
+	}
+
+	if l.cpArtistIdx >= 0 {
+		playing, _ = l.artistPane.GetItemText(l.cpArtistIdx)
+		playing = strings.TrimPrefix(playing, theme.Current.NowPlayingTag())
+	}
+
+	// names shown if the artist pane holds search results
+	var filtered []string
+	if l.artistPaneFiltered {
+		for i := range l.artistPane.GetItemCount() {
+			n, _ := l.artistPane.GetItemText(i)
+			filtered = append(filtered, strings.TrimPrefix(n, theme.Current.NowPlayingTag()))
+		}
+	}
+
+	artistOffset, _ := l.artistPane.GetOffset()
+	albumOffset, _ := l.albumPane.GetOffset()
+
+	// only the focused album is selectable, which is found by name as
+	// albums may have been sorted
+	albumIdx := l.selectedAlbumIdx()
+	trackRow := 0
+	var albumName string
+	if albumIdx >= 0 {
+		trackRow, _ = l.currentArtistAlbums[albumIdx].GetSelection()
+		if albums := l.albumArtists[selected].albums; albumIdx < len(albums) {
+			albumName = albums[albumIdx].name
+		}
+	}
+
+	l.albumArtists = artists
+	l.artists = order
+
+	if l.artistPaneFiltered {
+		l.FilterArtistPane(filtered)
+	} else {
+		l.DrawArtistPane()
+	}
+
+	l.cpArtistIdx = -1
+	if playing != "" {
+		l.MarkCpArtist(playing, "")
+	}
+
+	l.albumPane.Clear()
+	l.currentArtistAlbums = nil
+
+	if l.artistPane.GetItemCount() == 0 {
+		if albumIdx >= 0 {
+			l.app.SetFocus(l.artistPane)
+		}
+
+		return
+	}
+
+	idx := l.artistPane.FindItems(selected, "", false, true)
+	if selected == "" || len(idx) == 0 {
+		// the artist is gone, so its neighbour is shown from the top
+		idx = []int{min(current, l.artistPane.GetItemCount()-1)}
+		artistOffset, albumOffset = 0, 0
+
+		if albumIdx >= 0 {
+			l.app.SetFocus(l.artistPane)
+		}
+
+		albumIdx = -1
+	}
+
+	// albums are drawn below rather than by the changed func
+	l.artistPane.SetChangedFunc(nil)
+	l.artistPane.SetCurrentItem(idx[0])
+	l.artistPane.SetOffset(artistOffset, 0)
+	l.artistPane.SetChangedFunc(l.scrollCb)
+
+	name, _ := l.artistPane.GetItemText(idx[0])
+	l.scrollCb(idx[0], name, "", 0)
+	l.albumPane.SetOffset(albumOffset, 0)
+
+	if albumIdx < 0 {
+		return
+	}
+
+	albumIdx = slices.IndexFunc(l.albumArtists[selected].albums, func(al album) bool {
+		return al.name == albumName
+	})
+
+	// the album may be gone, in which case the artist is focused instead
+	if albumIdx < 0 || albumIdx >= len(l.currentArtistAlbums) {
+		l.app.SetFocus(l.artistPane)
+		return
+	}
+
+	al := l.currentArtistAlbums[albumIdx]
+	al.Select(min(trackRow, al.GetRowCount()-1), 0)
+	l.app.SetFocus(al)
+}
+
+// changes lists albums, as "artist - album", and artists that differ
+// between two versions of a library.
+type changes struct {
+	addedAlbums, removedAlbums, changedAlbums []string
+	addedArtists, removedArtists              []string
+}
+
+// diff returns the changes from library old to library new.
+func diff(old, new map[string]artist) changes {
+	var c changes
+
+	// albums of an artist are told apart by their browse keys
+	type key struct{ artist, browseKey string }
+
+	albums := func(lib map[string]artist) map[key]album {
+		m := map[key]album{}
+		for name, ar := range lib {
+			for _, al := range ar.albums {
+				m[key{name, al.source.BrowseKey}] = al
+			}
+		}
+
+		return m
+	}
+
+	oldAlbums, newAlbums := albums(old), albums(new)
+
+	for k, al := range newAlbums {
+		prev, ok := oldAlbums[k]
+		switch {
+		case !ok:
+			c.addedAlbums = append(c.addedAlbums, k.artist+" - "+al.name)
+		case prev.source != al.source:
+			c.changedAlbums = append(c.changedAlbums, k.artist+" - "+al.name)
+		}
+	}
+
+	for k, al := range oldAlbums {
+		if _, ok := newAlbums[k]; !ok {
+			c.removedAlbums = append(c.removedAlbums, k.artist+" - "+al.name)
+		}
+	}
+
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			c.addedArtists = append(c.addedArtists, name)
+		}
+	}
+
+	for name := range old {
+		if _, ok := new[name]; !ok {
+			c.removedArtists = append(c.removedArtists, name)
+		}
+	}
+
+	for _, s := range [][]string{c.addedAlbums, c.removedAlbums, c.changedAlbums,
+		c.addedArtists, c.removedArtists} {
+		slices.Sort(s)
+	}
+
+	return c
+}
+
+// String summarizes c, e.g. "library updated: 2 albums added, 1 artist added".
+func (c changes) String() string {
+	var parts []string
+
+	add := func(n int, what, how string) {
+		switch n {
+		case 0:
+		case 1:
+			parts = append(parts, fmt.Sprintf("1 %s %s", what, how))
+		default:
+			parts = append(parts, fmt.Sprintf("%d %ss %s", n, what, how))
+		}
+	}
+
+	add(len(c.addedAlbums), "album", "added")
+	add(len(c.removedAlbums), "album", "removed")
+	add(len(c.changedAlbums), "album", "changed")
+	add(len(c.addedArtists), "artist", "added")
+	add(len(c.removedArtists), "artist", "removed")
+
+	if len(parts) == 0 {
+		return "library is up to date"
+	}
+
+	return "library updated: " + strings.Join(parts, ", ")
+}
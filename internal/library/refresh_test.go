@@ -0,0 +1,197 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkozjak/blutui/cache"
+	"github.com/mkozjak/tview"
+)
+
+// fakeLibrary serves a local library listing albums, counting requests for
+// tracks per album. Tracks of albums in uncached are missing, as they are
+// from the cache while the player is offline.
+type fakeLibrary struct {
+	albums   []item
+	fetched  map[string]int
+	uncached map[string]bool
+}
+
+func (f *fakeLibrary) fetch(u string) ([]byte, error) {
+	switch {
+	case strings.HasSuffix(u, localRootEndpoint):
+		return []byte(`<browse><item browseKey="section"/></browse>`), nil
+	case strings.HasSuffix(u, "/Browse?key=section"):
+		var b strings.Builder
+		b.WriteString("<browse>")
+		for _, al := range f.albums {
+			fmt.Fprintf(&b, `<item text=%q text2=%q browseKey=%q tracks=%q/>`,
+				al.Text, al.Text2, al.BrowseKey, al.Tracks)
+		}
+		b.WriteString("</browse>")
+
+		return []byte(b.String()), nil
+	case strings.Contains(u, "/Songs?"):
+		return []byte(`<songs><song><date>1994</date></song></songs>`), nil
+	}
+
+	key, err := url.QueryUnescape(strings.TrimPrefix(u, "http://player/Browse?key="))
+	if err != nil {
+		return nil, err
+	}
+
+	if f.uncached[key] {
+		return nil, fmt.Errorf("%s: %w", u, cache.ErrNotCached)
+	}
+
+	f.fetched[key]++
+	return []byte(`<browse><item text="Track" duration="60"/></browse>`), nil
+}
+
+func TestLoadReusesAlbums(t *testing.T) {
+	f := &fakeLibrary{
+		albums: []item{
+			{Text: "Long Division", Text2: "Low", BrowseKey: "ld", Tracks: "11"},
+			{Text: "Secret Name", Text2: "Low", BrowseKey: "sn", Tracks: "12"},
+			{Text: "Blue", Text2: "Joni Mitchell", BrowseKey: "bl", Tracks: "10"},
+		},
+		fetched: map[string]int{},
+	}
+
+	l := &Library{API: "http://player", service: "local"}
+
+	old, err := l.load(f.fetch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	known := map[string]album{}
+	for _, ar := range old {
+		for _, al := range ar.albums {
+			known[al.source.BrowseKey] = al
+		}
+	}
+
+	// Secret Name gained a track, Blue is gone and Vespertine is new
+	f.albums = []item{
+		{Text: "Long Division", Text2: "Low", BrowseKey: "ld", Tracks: "11"},
+		{Text: "Secret Name", Text2: "Low", BrowseKey: "sn", Tracks: "13"},
+		{Text: "Vespertine", Text2: "Björk", BrowseKey: "ve", Tracks: "12"},
+	}
+
+	artists, err := l.load(f.fetch, known)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"ld": 1, "sn": 2, "bl": 1, "ve": 1}
+	for k, n := range want {
+		if f.fetched[k] != n {
+			t.Errorf("fetched tracks of %s %d times, want %d", k, f.fetched[k], n)
+		}
+	}
+
+	if al := artists["Low"].albums; len(al) != 2 || al[0].year != 1994 || al[0].duration != 60 {
+		t.Errorf("albums of Low %+v", al)
+	}
+
+	c := diff(old, artists)
+
+	if !slices.Equal(c.addedAlbums, []string{"Björk - Vespertine"}) ||
+		!slices.Equal(c.removedAlbums, []string{"Joni Mitchell - Blue"}) ||
+		!slices.Equal(c.changedAlbums, []string{"Low - Secret Name"}) ||
+		!slices.Equal(c.addedArtists, []string{"Björk"}) ||
+		!slices.Equal(c.removedArtists, []string{"Joni Mitchell"}) {
+		t.Errorf("changes %+v", c)
+	}
+
+	s := "library updated: 1 album added, 1 album removed, 1 album changed, 1 artist added, 1 artist removed"
+	if c.String() != s {
+		t.Errorf("summary %q, want %q", c.String(), s)
+	}
+
+	if s := diff(artists, artists).String(); s != "library is up to date" {
+		t.Errorf("summary without changes %q", s)
+	}
+}
+
+func TestLoadPartialCache(t *testing.T) {
+	f := &fakeLibrary{
+		albums: []item{
+			{Text: "Long Division", Text2: "Low", BrowseKey: "ld"},
+			{Text: "Secret Name", Text2: "Low", BrowseKey: "sn"},
+			{Text: "Blue", Text2: "Joni Mitchell", BrowseKey: "bl"},
+		},
+		fetched:  map[string]int{},
+		uncached: map[string]bool{"sn": true},
+	}
+
+	l := &Library{API: "http://player", service: "local"}
+
+	artists, err := l.load(f.fetch, nil)
+	if !errors.Is(err, errNotCached) || err.Error() != "1 of 3 albums are missing from the cache" {
+		t.Fatalf("got error %v", err)
+	}
+
+	if len(artists["Low"].albums) != 1 || len(artists["Joni Mitchell"].albums) != 1 {
+		t.Errorf("got %d albums by Low and %d by Joni Mitchell, want 1 each",
+			len(artists["Low"].albums), len(artists["Joni Mitchell"].albums))
+	}
+}
+
+// fakeApp focuses primitives and runs updates right away, as if it was the
+// event loop.
+type fakeApp struct {
+	focused tview.Primitive
+}
+
+func (f *fakeApp) SetFocus(p tview.Primitive) *tview.Application {
+	if f.focused != nil {
+		f.focused.Blur()
+	}
+
+	f.focused = p
+	p.Focus(func(tview.Primitive) {})
+
+	return nil
+}
+
+func (f *fakeApp) PrevFocused() tview.Primitive                { return nil }
+func (f *fakeApp) SetPrevFocused(string)                       {}
+func (f *fakeApp) ShowPopup(tview.Primitive, int, int)         {}
+func (f *fakeApp) HidePopup()                                  {}
+func (f *fakeApp) QueueUpdateDraw(u func()) *tview.Application { u(); return nil }
+
+func TestReplaceKeepsAlbum(t *testing.T) {
+	fa := &fakeApp{}
+	l := &Library{app: fa, cpArtistIdx: -1}
+	l.CreateContainer()
+
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	albums := []album{
+		{name: "Secret Name", metadata: metadata{year: 1999}, firstSeen: day(1), tracks: []track{{name: "Starfire"}}},
+		{name: "Hey What", metadata: metadata{year: 2021}, firstSeen: day(3), tracks: []track{{name: "White Horses"}}},
+		{name: "Double Negative", metadata: metadata{year: 2018}, firstSeen: day(2), tracks: []track{{name: "Quorum"}}},
+	}
+
+	artists := map[string]artist{"Low": {albums: albums}}
+	sortAlbums(albums, ByYear)
+	l.replace(artists, []string{"Low"})
+
+	// focus Double Negative, the second album by year
+	fa.SetFocus(l.currentArtistAlbums[1])
+
+	// as done by cycleSort
+	albums = slices.Clone(albums)
+	sortAlbums(albums, ByTitle)
+	l.replace(map[string]artist{"Low": {albums: albums}}, []string{"Low"})
+
+	if i := l.selectedAlbumIdx(); i != 0 || fa.focused != l.currentArtistAlbums[0] {
+		t.Errorf("album %d is focused, want Double Negative first by title", i)
+	}
+}
@@ -0,0 +1,320 @@
+package library
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An ArtistSort is an order of the artist pane.
+type ArtistSort string
+
+const (
+	ByName         ArtistSort = "name"     // alphabetically
+	ByNameArticles ArtistSort = "articles" // alphabetically, ignoring leading articles
+	ByAlbumCount   ArtistSort = "albums"   // most albums first
+	ByLastPlayed   ArtistSort = "played"   // most recently played first
+)
+
+// artistSorts holds artist orders in the order they are cycled.
+var artistSorts = []ArtistSort{ByName, ByNameArticles, ByAlbumCount, ByLastPlayed}
+
+func (s ArtistSort) String() string {
+	switch s {
+	case ByNameArticles:
+		return "name ignoring articles"
+	case ByAlbumCount:
+		return "album count"
+	case ByLastPlayed:
+		return "last played"
+	}
+
+	return "name"
+}
+
+// An AlbumSort is an order of albums of an artist.
+type AlbumSort string
+
+const (
+	ByYear     AlbumSort = "year"     // oldest first
+	ByYearDesc AlbumSort = "yearDesc" // newest first
+	ByTitle    AlbumSort = "title"    // alphabetically
+	ByAdded    AlbumSort = "added"    // most recently added first
+)
+
+// albumSorts holds album orders in the order they are cycled.
+var albumSorts = []AlbumSort{ByYear, ByYearDesc, ByTitle, ByAdded}
+
+func (s AlbumSort) String() string {
+	switch s {
+	case ByYearDesc:
+		return "year, newest first"
+	case ByTitle:
+		return "title"
+	case ByAdded:
+		return "date added"
+	}
+
+	return "year"
+}
+
+// Sort holds the orders of a library page.
+type Sort struct {
+	Artists ArtistSort `json:"artists"`
+	Albums  AlbumSort  `json:"albums"`
+}
+
+// next returns the item following s in sorts, wrapping around.
+func next[T comparable](sorts []T, s T) T {
+	return sorts[(slices.Index(sorts, s)+1)%len(sorts)]
+}
+
+// PlayHistory is implemented by types that know when artists were played,
+// such as [history.Store].
+type PlayHistory interface {
+	LastPlayed() (map[string]time.Time, error)
+}
+
+// articles are words ignored at the start of artist names by [ByNameArticles].
+var articles = []string{"the ", "a ", "an ", "die ", "der ", "das ", "le ", "la ", "les "}
+
+// sortKey returns a lowercase artist name to sort by, without a leading
+// article if ignoreArticles is set.
+func sortKey(name string, ignoreArticles bool) string {
+	key := strings.ToLower(name)
+	if !ignoreArticles {
+		return key
+	}
+
+	for _, a := range articles {
+		if rest, ok := strings.CutPrefix(key, a); ok && rest != "" {
+			return rest
+		}
+	}
+
+	return key
+}
+
+// sortArtists returns names of artists in order s. Times artists were last
+// played, by lowercase name, are only needed for [ByLastPlayed].
+func sortArtists(artists map[string]artist, s ArtistSort, played map[string]time.Time) []string {
+	names := make([]string, 0, len(artists))
+	for n := range artists {
+		names = append(names, n)
+	}
+
+	byName := func(i, j int) bool {
+		return sortKey(names[i], s == ByNameArticles) < sortKey(names[j], s == ByNameArticles)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		switch s {
+		case ByAlbumCount:
+			if a, b := len(artists[names[i]].albums), len(artists[names[j]].albums); a != b {
+				return a > b
+			}
+		case ByLastPlayed:
+			a, b := played[strings.ToLower(names[i])], played[strings.ToLower(names[j])]
+			if !a.Equal(b) {
+				return a.After(b)
+			}
+		}
+
+		return byName(i, j)
+	})
+
+	return names
+}
+
+// sortAlbums sorts albums in order s, falling back to year and title.
+func sortAlbums(albums []album, s AlbumSort) {
+	sort.SliceStable(albums, func(i, j int) bool {
+		a, b := albums[i], albums[j]
+
+		switch s {
+		case ByYearDesc:
+			if a.year != b.year {
+				return a.year > b.year
+			}
+		case ByTitle:
+			if ta, tb := strings.ToLower(a.name), strings.ToLower(b.name); ta != tb {
+				return ta < tb
+			}
+		case ByAdded:
+			if !a.firstSeen.Equal(b.firstSeen) {
+				return a.firstSeen.After(b.firstSeen)
+			}
+		}
+
+		if a.year != b.year {
+			return a.year < b.year
+		}
+
+		return strings.ToLower(a.name) < strings.ToLower(b.name)
+	})
+}
+
+// SortFile is the name of the file in the state directory holding orders
+// chosen per library page.
+const SortFile = "sort.json"
+
+// sortMu guards the [SortFile], which is shared by libraries.
+var sortMu sync.Mutex
+
+// loadSort returns the orders chosen for the library of service, or the
+// default ones if none were.
+func loadSort(path, service string) (Sort, error) {
+	sortMu.Lock()
+	defer sortMu.Unlock()
+
+	s := Sort{Artists: ByName, Albums: ByYear}
+
+	sorts, err := readSorts(path)
+	if err != nil {
+		return s, err
+	}
+
+	if ss, ok := sorts[service]; ok {
+		if slices.Contains(artistSorts, ss.Artists) {
+			s.Artists = ss.Artists
+		}
+
+		if slices.Contains(albumSorts, ss.Albums) {
+			s.Albums = ss.Albums
+		}
+	}
+
+	return s, nil
+}
+
+// saveSort records orders s chosen for the library of service.
+func saveSort(path, service string, s Sort) error {
+	sortMu.Lock()
+	defer sortMu.Unlock()
+
+	sorts, err := readSorts(path)
+	if err != nil {
+		return err
+	}
+
+	sorts[service] = s
+
+	data, err := json.Marshal(sorts)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// readSorts reads orders of all library pages from the [SortFile] at path.
+func readSorts(path string) (map[string]Sort, error) {
+	sorts := map[string]Sort{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return sorts, nil
+		}
+
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &sorts); err != nil {
+		return nil, err
+	}
+
+	return sorts, nil
+}
+
+// sorting returns the orders chosen for the library.
+func (l *Library) sorting() Sort {
+	l.sortMutex.Lock()
+	defer l.sortMutex.Unlock()
+
+	return l.sort
+}
+
+// lastPlayed returns times artists were last played, by lowercase name, if
+// order s needs them. As it reads the listening history, it's called off
+// the UI goroutine.
+func (l *Library) lastPlayed(s ArtistSort) map[string]time.Time {
+	if s != ByLastPlayed || l.history == nil {
+		return nil
+	}
+
+	played, err := l.history.LastPlayed()
+	if err != nil {
+		slog.Warn("loading listening history", "err", err)
+	}
+
+	return played
+}
+
+// orderArtists returns names of artists in order s. It's called off the UI
+// goroutine, see [Library.lastPlayed].
+func (l *Library) orderArtists(artists map[string]artist, s ArtistSort) []string {
+	return sortArtists(artists, s, l.lastPlayed(s))
+}
+
+// cycleSort switches the focused pane to its next order and redraws the
+// library, keeping the selection. The choice is kept for the next start.
+func (l *Library) cycleSort() {
+	var msg string
+
+	l.sortMutex.Lock()
+	artistsFocused := l.artistPane.HasFocus()
+	if artistsFocused {
+		l.sort.Artists = next(artistSorts, l.sort.Artists)
+		msg = "artists sorted by " + l.sort.Artists.String()
+	} else {
+		l.sort.Albums = next(albumSorts, l.sort.Albums)
+		msg = "albums sorted by " + l.sort.Albums.String()
+	}
+	s := l.sort
+	l.sortMutex.Unlock()
+
+	// albums are sorted in copies, so that the focused one can still be
+	// told by replace
+	if !artistsFocused {
+		sorted := make(map[string]artist, len(l.albumArtists))
+		for name, ar := range l.albumArtists {
+			ar.albums = slices.Clone(ar.albums)
+			sortAlbums(ar.albums, s.Albums)
+			sorted[name] = ar
+		}
+
+		l.replace(sorted, l.artists)
+	}
+
+	go func() {
+		if artistsFocused {
+			played := l.lastPlayed(s.Artists)
+
+			l.app.QueueUpdateDraw(func() {
+				// skipped if the order was changed again meanwhile
+				if l.sorting().Artists == s.Artists {
+					l.replace(l.albumArtists, sortArtists(l.albumArtists, s.Artists, played))
+				}
+			})
+		}
+
+		l.notifier.Info(msg)
+
+		if l.sortPath == "" {
+			return
+		}
+
+		// the latest order is saved if it was changed again meanwhile
+		if err := saveSort(l.sortPath, l.service, l.sorting()); err != nil {
+			l.notifier.Warn("saving sort order", err, "service", l.service)
+		}
+	}()
+}
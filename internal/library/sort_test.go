@@ -0,0 +1,99 @@
+package library
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSortArtists(t *testing.T) {
+	artists := map[string]artist{
+		"The Cure":             {albums: make([]album, 1)},
+		"Low":                  {albums: make([]album, 3)},
+		"Die Ärzte":            {albums: make([]album, 2)},
+		"beach house":          {albums: make([]album, 1)},
+		"A Tribe Called Quest": {albums: make([]album, 1)},
+	}
+
+	played := map[string]time.Time{
+		"beach house": time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"the cure":    time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		sort ArtistSort
+		want []string
+	}{
+		{ByName, []string{"A Tribe Called Quest", "beach house", "Die Ärzte", "Low", "The Cure"}},
+		{ByNameArticles, []string{"beach house", "The Cure", "Low", "A Tribe Called Quest", "Die Ärzte"}},
+		{ByAlbumCount, []string{"Low", "Die Ärzte", "A Tribe Called Quest", "beach house", "The Cure"}},
+		{ByLastPlayed, []string{"The Cure", "beach house", "A Tribe Called Quest", "Die Ärzte", "Low"}},
+	}
+
+	for _, tt := range tests {
+		if got := sortArtists(artists, tt.sort, played); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.sort, got, tt.want)
+		}
+	}
+}
+
+func TestSortAlbums(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+
+	albums := []album{
+		{name: "Secret Name", metadata: metadata{year: 1999}, firstSeen: day(1)},
+		{name: "Hey What", metadata: metadata{year: 2021}, firstSeen: day(3)},
+		{name: "Double Negative", metadata: metadata{year: 2018}, firstSeen: day(1)},
+		{name: "a Lifetime", metadata: metadata{year: 1999}, firstSeen: day(2)},
+	}
+
+	tests := []struct {
+		sort AlbumSort
+		want []string
+	}{
+		{ByYear, []string{"a Lifetime", "Secret Name", "Double Negative", "Hey What"}},
+		{ByYearDesc, []string{"Hey What", "Double Negative", "a Lifetime", "Secret Name"}},
+		{ByTitle, []string{"a Lifetime", "Double Negative", "Hey What", "Secret Name"}},
+		{ByAdded, []string{"Hey What", "a Lifetime", "Secret Name", "Double Negative"}},
+	}
+
+	for _, tt := range tests {
+		sortAlbums(albums, tt.sort)
+
+		var got []string
+		for _, al := range albums {
+			got = append(got, al.name)
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.sort, got, tt.want)
+		}
+	}
+}
+
+func TestSaveSort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SortFile)
+
+	s, err := loadSort(path, "local")
+	if err != nil || s != (Sort{ByName, ByYear}) {
+		t.Fatalf("without a file got %+v, %v", s, err)
+	}
+
+	s = Sort{next(artistSorts, s.Artists), next(albumSorts, ByAdded)}
+	if err := saveSort(path, "local", s); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveSort(path, "tidal", Sort{ByLastPlayed, ByTitle}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := loadSort(path, "local"); err != nil || got != (Sort{ByNameArticles, ByYear}) {
+		t.Errorf("got %+v, %v", got, err)
+	}
+
+	if got, _ := loadSort(path, "tidal"); got != (Sort{ByLastPlayed, ByTitle}) {
+		t.Errorf("tidal got %+v", got)
+	}
+}
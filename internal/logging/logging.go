@@ -0,0 +1,84 @@
+// Package logging sets up blutui's structured log, which is written to
+// a rotated file in the state directory.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mkozjak/blutui/internal/config"
+)
+
+// File is the name of the log file in the state directory.
+const File = "blutui.log"
+
+// ParseLevel returns the level named s, one of debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+
+	switch strings.ToLower(s) {
+	case "debug", "info", "warn", "error":
+		err := l.UnmarshalText([]byte(s))
+		return l, err
+	}
+
+	return l, fmt.Errorf("unknown log level %q", s)
+}
+
+// Setup makes the default logger write records of level and above to the
+// [File] in the state directory. Records of a running instance are told apart
+// by its pid. If the file can't be opened, the error is returned and logs are
+// discarded, so that logging never gets in the way of using blutui.
+func Setup(level slog.Level) (io.Closer, error) {
+	var w io.WriteCloser = discard{}
+
+	path, err := config.StatePath(File)
+	if err == nil {
+		var f *rotatingFile
+		if f, err = openRotating(path, maxSize, backups); err == nil {
+			w = f
+		}
+	}
+
+	h := slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(h).With("pid", os.Getpid()))
+
+	return w, err
+}
+
+// discard is a log destination used when the log file can't be opened.
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
+func (discard) Close() error                { return nil }
+
+// Transport is an [http.RoundTripper] logging every request made through
+// Base with its endpoint, status and latency at debug level, and failed
+// requests at warn level.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+
+	attrs := []any{
+		"method", req.Method,
+		"endpoint", req.URL.Path,
+		"latency", time.Since(start).Round(time.Millisecond),
+	}
+
+	if err != nil {
+		slog.Warn("request failed", append(attrs, "err", err)...)
+		return resp, err
+	}
+
+	slog.Debug("request", append(attrs, "status", resp.StatusCode)...)
+	return resp, nil
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		if l, err := ParseLevel(s); err != nil || l != want {
+			t.Errorf("ParseLevel(%s) = %v, %v, want %v", s, l, err, want)
+		}
+	}
+
+	for _, s := range []string{"", "verbose", "info+2"} {
+		if _, err := ParseLevel(s); err == nil {
+			t.Errorf("ParseLevel(%q) should fail", s)
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), File)
+
+	r, err := openRotating(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	} {
+		if b, _ := os.ReadFile(name); string(b) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), b, want)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("more backups than allowed are kept")
+	}
+}
+
+func TestSetupUnwritable(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	dir := t.TempDir()
+	os.Chmod(dir, 0500)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+
+	lf, err := Setup(slog.LevelInfo)
+	if err == nil && os.Getuid() != 0 {
+		t.Error("Setup should report the log file can't be created")
+	}
+
+	slog.Info("logging without a file")
+	lf.Close()
+}
+
+func TestTransport(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var b bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := &http.Client{Transport: Transport{Base: http.DefaultTransport}}
+
+	resp, err := c.Get(srv.URL + "/Browse?key=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	out := b.String()
+	for _, want := range []string{"level=DEBUG", "method=GET", "endpoint=/Browse", "status=404", "latency="} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q is missing %s", out, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+const (
+	// maxSize is the size in bytes a log file grows to before it's rotated.
+	maxSize = 5 << 20
+
+	// backups is the number of rotated log files kept, named File.1 being
+	// the most recent up to File.<backups>.
+	backups = 3
+)
+
+// rotatingFile is a log file that is renamed to path.1 once it grows past
+// its size limit, shifting older ones and removing the oldest.
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	limit   int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+// openRotating opens the log file at path for appending.
+func openRotating(path string, limit int64, backups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, limit: limit, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.file, r.size = f, fi.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.limit {
+		// keep writing to the current file if it can't be rotated
+		r.rotate()
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// rotate shifts backups by one, moves the current file to path.1 and
+// opens a new one.
+func (r *rotatingFile) rotate() error {
+	for i := r.backups - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+
+	old := r.file
+	if err := r.open(); err != nil {
+		r.file = old
+		return err
+	}
+
+	old.Close()
+	return nil
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
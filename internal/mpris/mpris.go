@@ -0,0 +1,477 @@
+// Package mpris exports the player on the D-Bus session bus using the MPRIS
+// specification, so that media keys, desktop widgets and tools such as
+// playerctl can control it.
+package mpris
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+const (
+	busName     = "org.mpris.MediaPlayer2.blutui"
+	objPath     = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	rootIface   = "org.mpris.MediaPlayer2"
+	playerIface = "org.mpris.MediaPlayer2.Player"
+	propsIface  = "org.freedesktop.DBus.Properties"
+	noTrack     = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+	trackPrefix = "/org/mpris/MediaPlayer2/blutui/track/"
+)
+
+// errInvalidArgs is replied to calls with arguments out of range.
+const errInvalidArgs = "org.freedesktop.DBus.Error.InvalidArgs"
+
+// seekTolerance is how far, in seconds, the position reported by the player
+// may differ from the expected one before a Seeked signal is emitted.
+const seekTolerance = 3
+
+// loopStatuses maps player's repeat modes to MPRIS loop statuses.
+var loopStatuses = []string{"Playlist", "Track", "None"}
+
+// Controller controls the player on behalf of MPRIS clients.
+type Controller interface {
+	player.Controller
+	Pause()
+	Resume()
+	Seek(secs int)
+	SetVolume(level int)
+	SetRepeatMode(mode int)
+	SetShuffle(on bool)
+}
+
+// Bridge exports a [Controller] as an MPRIS media player and keeps its
+// properties in sync with player status updates.
+type Bridge struct {
+	conn     *dbus.Conn
+	props    *prop.Properties
+	player   Controller
+	notifier notify.Notifier
+	api      string
+
+	mu      sync.Mutex
+	status  player.Status
+	updated time.Time
+}
+
+// Start connects to the session bus and exports the player as
+// org.mpris.MediaPlayer2.blutui. If that name is taken, e.g. by another
+// blutui instance, a unique instance name is used instead. Artwork paths
+// are resolved against api, and failures of the bus are reported to n.
+// Properties start from the player's current status.
+func Start(p Controller, api string, n notify.Notifier) (*Bridge, error) {
+	c, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+
+	return start(c, p, api, n)
+}
+
+func start(c *dbus.Conn, p Controller, api string, n notify.Notifier) (*Bridge, error) {
+	b := &Bridge{conn: c, player: p, notifier: n, api: api, status: p.Status(), updated: time.Now()}
+
+	if err := b.export(); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	for _, name := range []string{busName, fmt.Sprintf("%s.instance%d", busName, os.Getpid())} {
+		// do not queue for the name if it is taken
+		r, err := c.RequestName(name, dbus.NameFlagDoNotQueue)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+
+		if r == dbus.RequestNameReplyPrimaryOwner || r == dbus.RequestNameReplyAlreadyOwner {
+			return b, nil
+		}
+	}
+
+	c.Close()
+	return nil, fmt.Errorf("mpris: can't acquire bus name %s", busName)
+}
+
+// export exports methods, properties and introspection data of the root
+// and player interfaces.
+func (b *Bridge) export() error {
+	root := mediaPlayer{}
+	pl := mediaPlayerPlayer{b}
+
+	if err := b.conn.Export(root, objPath, rootIface); err != nil {
+		return err
+	}
+
+	if err := b.conn.ExportWithMap(pl, playerMethods, objPath, playerIface); err != nil {
+		return err
+	}
+
+	props, err := prop.Export(b.conn, objPath, prop.Map{
+		rootIface:   b.rootProps(),
+		playerIface: b.playerProps(),
+	})
+	if err != nil {
+		return err
+	}
+
+	b.props = props
+
+	// the position moves on its own, so it's read when asked for
+	if err := b.conn.Export(properties{props, b}, objPath, propsIface); err != nil {
+		return err
+	}
+
+	node := &introspect.Node{
+		Name: string(objPath),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       rootIface,
+				Methods:    introspect.Methods(root),
+				Properties: props.Introspection(rootIface),
+			},
+			{
+				Name:       playerIface,
+				Methods:    playerIntrospection(pl),
+				Properties: props.Introspection(playerIface),
+				Signals: []introspect.Signal{
+					{Name: "Seeked", Args: []introspect.Arg{{Name: "Position", Type: "x"}}},
+				},
+			},
+		},
+	}
+
+	return b.conn.Export(introspect.NewIntrospectable(node), objPath, "org.freedesktop.DBus.Introspectable")
+}
+
+// Close disconnects from the session bus.
+func (b *Bridge) Close() error {
+	return b.conn.Close()
+}
+
+// Listen consumes player status updates given its read-only channel,
+// emitting PropertiesChanged signals for properties that changed.
+func (b *Bridge) Listen(ch <-chan player.Status) {
+	for s := range ch {
+		if s.State == "offline" || s.State == "ctrlerr" {
+			continue
+		}
+
+		b.mu.Lock()
+		expected := b.position()
+		prevTrack := b.props.GetMust(playerIface, "Metadata")
+
+		b.status = s
+		b.updated = time.Now()
+
+		props := b.playerProps()
+		b.mu.Unlock()
+
+		for k, p := range props {
+			if p.Emit != prop.EmitTrue || reflect.DeepEqual(p.Value, b.props.GetMust(playerIface, k)) {
+				continue
+			}
+
+			b.props.SetMust(playerIface, k, p.Value)
+		}
+
+		seeked := reflect.DeepEqual(prevTrack, props["Metadata"].Value) &&
+			abs(expected-int64(s.Secs)*1e6) > seekTolerance*1e6
+
+		if seeked {
+			if err := b.conn.Emit(objPath, playerIface+".Seeked", int64(s.Secs)*1e6); err != nil {
+				b.notifier.Warn("emitting MPRIS seek", err)
+			}
+		}
+	}
+}
+
+// position returns the playing position in microseconds, interpolated
+// since the last status update while playing. Callers hold b.mu.
+func (b *Bridge) position() int64 {
+	pos := time.Duration(b.status.Secs) * time.Second
+	if b.status.State == "play" || b.status.State == "stream" {
+		pos += time.Since(b.updated)
+	}
+
+	if b.status.TrackLen > 0 && pos > time.Duration(b.status.TrackLen)*time.Second {
+		pos = time.Duration(b.status.TrackLen) * time.Second
+	}
+
+	return pos.Microseconds()
+}
+
+func (b *Bridge) rootProps() map[string]*prop.Prop {
+	constant := func(v any) *prop.Prop { return &prop.Prop{Value: v, Emit: prop.EmitConst} }
+
+	return map[string]*prop.Prop{
+		"CanQuit":             constant(false),
+		"CanRaise":            constant(false),
+		"HasTrackList":        constant(false),
+		"Identity":            constant("blutui"),
+		"SupportedUriSchemes": constant([]string{}),
+		"SupportedMimeTypes":  constant([]string{}),
+	}
+}
+
+// playerProps returns properties of the player interface. Callers hold b.mu.
+func (b *Bridge) playerProps() map[string]*prop.Prop {
+	s := b.status
+
+	status := "Stopped"
+	switch s.State {
+	case "play", "stream":
+		status = "Playing"
+	case "pause":
+		status = "Paused"
+	}
+
+	loop := "None"
+	if s.Repeat >= 0 && s.Repeat < len(loopStatuses) {
+		loop = loopStatuses[s.Repeat]
+	}
+
+	volume := float64(s.Volume) / 100
+	if s.Mute == 1 {
+		volume = 0
+	}
+
+	read := func(v any) *prop.Prop { return &prop.Prop{Value: v, Emit: prop.EmitTrue} }
+
+	return map[string]*prop.Prop{
+		"PlaybackStatus": read(status),
+		"LoopStatus":     {Value: loop, Writable: true, Emit: prop.EmitTrue, Callback: b.setLoopStatus},
+		"Rate":           {Value: 1.0, Writable: true, Emit: prop.EmitTrue, Callback: b.setRate},
+		"Shuffle":        {Value: s.Shuffle == 1, Writable: true, Emit: prop.EmitTrue, Callback: b.setShuffle},
+		"Metadata":       read(b.metadata()),
+		"Volume":         {Value: volume, Writable: true, Emit: prop.EmitTrue, Callback: b.setVolume},
+		"Position":       {Value: b.position(), Emit: prop.EmitFalse},
+		"MinimumRate":    read(1.0),
+		"MaximumRate":    read(1.0),
+		"CanGoNext":      read(true),
+		"CanGoPrevious":  read(true),
+		"CanPlay":        read(true),
+		"CanPause":       read(true),
+		"CanSeek":        read(s.TrackLen > 0),
+		"CanControl":     read(true),
+	}
+}
+
+// metadata returns the current track's metadata. Callers hold b.mu.
+func (b *Bridge) metadata() map[string]dbus.Variant {
+	s := b.status
+
+	title, artist, album := s.Track, s.Artist, s.Album
+	if s.State == "stream" {
+		// radio stations put their metadata into title lines
+		title, artist, album = s.Title2, s.Title3, ""
+	}
+
+	if title == "" {
+		return map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(noTrack)}
+	}
+
+	m := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(b.trackID()),
+		"xesam:title":   dbus.MakeVariant(title),
+	}
+
+	if artist != "" {
+		m["xesam:artist"] = dbus.MakeVariant([]string{artist})
+	}
+
+	if album != "" {
+		m["xesam:album"] = dbus.MakeVariant(album)
+	}
+
+	if s.TrackLen > 0 {
+		m["mpris:length"] = dbus.MakeVariant(int64(s.TrackLen) * 1e6)
+	}
+
+	if s.Image != "" {
+		art := s.Image
+		if strings.HasPrefix(art, "/") {
+			art = b.api + art
+		}
+
+		m["mpris:artUrl"] = dbus.MakeVariant(art)
+	}
+
+	return m
+}
+
+// trackID returns an object path identifying the current track in the queue.
+// Callers hold b.mu.
+func (b *Bridge) trackID() dbus.ObjectPath {
+	return dbus.ObjectPath(fmt.Sprintf("%s%d", trackPrefix, b.status.Song))
+}
+
+func (b *Bridge) setVolume(c *prop.Change) *dbus.Error {
+	go b.player.SetVolume(int(c.Value.(float64)*100 + 0.5))
+	return nil
+}
+
+func (b *Bridge) setLoopStatus(c *prop.Change) *dbus.Error {
+	for mode, l := range loopStatuses {
+		if l == c.Value {
+			go b.player.SetRepeatMode(mode)
+			return nil
+		}
+	}
+
+	return dbus.NewError(errInvalidArgs, []any{fmt.Sprintf("unknown loop status %v", c.Value)})
+}
+
+func (b *Bridge) setShuffle(c *prop.Change) *dbus.Error {
+	go b.player.SetShuffle(c.Value.(bool))
+	return nil
+}
+
+// setRate accepts only the normal playback rate.
+func (b *Bridge) setRate(c *prop.Change) *dbus.Error {
+	if c.Value != 1.0 {
+		return dbus.NewError(errInvalidArgs, []any{"only the normal playback rate is supported"})
+	}
+
+	return nil
+}
+
+// properties serves the org.freedesktop.DBus.Properties interface, reading
+// the position from the bridge when it's asked for.
+type properties struct {
+	*prop.Properties
+	b *Bridge
+}
+
+func (p properties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	if iface == playerIface && name == "Position" {
+		p.b.mu.Lock()
+		defer p.b.mu.Unlock()
+
+		return dbus.MakeVariant(p.b.position()), nil
+	}
+
+	return p.Properties.Get(iface, name)
+}
+
+func (p properties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	props, err := p.Properties.GetAll(iface)
+	if err != nil || iface != playerIface {
+		return props, err
+	}
+
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+
+	props["Position"] = dbus.MakeVariant(p.b.position())
+	return props, nil
+}
+
+// mediaPlayer implements methods of the org.mpris.MediaPlayer2 interface.
+// blutui runs in a terminal that can't be raised, nor should it be quit
+// remotely.
+type mediaPlayer struct{}
+
+func (mediaPlayer) Raise() *dbus.Error { return nil }
+func (mediaPlayer) Quit() *dbus.Error  { return nil }
+
+// mediaPlayerPlayer implements methods of the org.mpris.MediaPlayer2.Player
+// interface.
+type mediaPlayerPlayer struct {
+	b *Bridge
+}
+
+func (m mediaPlayerPlayer) Next() *dbus.Error {
+	go m.b.player.Next()
+	return nil
+}
+
+func (m mediaPlayerPlayer) Previous() *dbus.Error {
+	go m.b.player.Previous()
+	return nil
+}
+
+func (m mediaPlayerPlayer) Pause() *dbus.Error {
+	go m.b.player.Pause()
+	return nil
+}
+
+func (m mediaPlayerPlayer) PlayPause() *dbus.Error {
+	go m.b.player.Playpause()
+	return nil
+}
+
+func (m mediaPlayerPlayer) Stop() *dbus.Error {
+	go m.b.player.Stop()
+	return nil
+}
+
+func (m mediaPlayerPlayer) Play() *dbus.Error {
+	go m.b.player.Resume()
+	return nil
+}
+
+// SeekBy seeks by offset microseconds from the current position. It's
+// exported as Seek, which go vet expects to be an [io.Seeker].
+func (m mediaPlayerPlayer) SeekBy(offset int64) *dbus.Error {
+	m.b.mu.Lock()
+	pos := m.b.position() + offset
+	m.b.mu.Unlock()
+
+	go m.b.player.Seek(int(pos / 1e6))
+	return nil
+}
+
+// SetPosition seeks to pos microseconds into track id. Requests for stale
+// tracks or positions out of range are ignored.
+func (m mediaPlayerPlayer) SetPosition(id dbus.ObjectPath, pos int64) *dbus.Error {
+	m.b.mu.Lock()
+	current := id == m.b.trackID()
+	length := int64(m.b.status.TrackLen) * 1e6
+	m.b.mu.Unlock()
+
+	if current && pos >= 0 && pos <= length {
+		go m.b.player.Seek(int(pos / 1e6))
+	}
+
+	return nil
+}
+
+func (m mediaPlayerPlayer) OpenUri(uri string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.NotSupported", []any{"opening URIs is not supported"})
+}
+
+// playerMethods maps methods of [mediaPlayerPlayer] to names on the bus
+// where they differ.
+var playerMethods = map[string]string{"SeekBy": "Seek"}
+
+// playerIntrospection describes methods of m by their names on the bus.
+func playerIntrospection(m mediaPlayerPlayer) []introspect.Method {
+	methods := introspect.Methods(m)
+	for i, mt := range methods {
+		if name, ok := playerMethods[mt.Name]; ok {
+			methods[i].Name = name
+		}
+	}
+
+	return methods
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
@@ -0,0 +1,206 @@
+package mpris
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+// fakePlayer records calls made by the bridge.
+type fakePlayer struct {
+	calls chan string
+}
+
+func (f *fakePlayer) record(format string, a ...any) { f.calls <- fmt.Sprintf(format, a...) }
+
+func (f *fakePlayer) Play(url string)        { f.record("play %s", url) }
+func (f *fakePlayer) Playpause()             { f.record("playpause") }
+func (f *fakePlayer) Stop()                  { f.record("stop") }
+func (f *fakePlayer) Next()                  { f.record("next") }
+func (f *fakePlayer) Previous()              { f.record("previous") }
+func (f *fakePlayer) VolumeUp(big bool)      { f.record("volumeup %v", big) }
+func (f *fakePlayer) VolumeDown(big bool)    { f.record("volumedown %v", big) }
+func (f *fakePlayer) Status() player.Status  { return player.Status{} }
+func (f *fakePlayer) ToggleMute()            { f.record("togglemute") }
+func (f *fakePlayer) ToggleRepeatMode()      { f.record("togglerepeat") }
+func (f *fakePlayer) State() string          { return "" }
+func (f *fakePlayer) Online() bool           { return true }
+func (f *fakePlayer) Pause()                 { f.record("pause") }
+func (f *fakePlayer) Resume()                { f.record("resume") }
+func (f *fakePlayer) Seek(secs int)          { f.record("seek %d", secs) }
+func (f *fakePlayer) SetVolume(level int)    { f.record("volume %d", level) }
+func (f *fakePlayer) SetRepeatMode(mode int) { f.record("repeat %d", mode) }
+func (f *fakePlayer) SetShuffle(on bool)     { f.record("shuffle %v", on) }
+
+// privateBus starts a dbus-daemon for the test and returns its address.
+func privateBus(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+
+	addr := "unix:path=" + filepath.Join(t.TempDir(), "bus")
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address", "--address="+addr)
+
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Skip("can't start dbus-daemon:", err)
+	}
+
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	// the daemon prints its address once it is ready
+	line, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimSpace(line)
+}
+
+func TestBridge(t *testing.T) {
+	addr := privateBus(t)
+	fp := &fakePlayer{calls: make(chan string, 16)}
+
+	bc, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := start(bc, fp, "http://bluesound.lan:11000", notify.Log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	statuses := make(chan player.Status)
+	go b.Listen(statuses)
+
+	c, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := c.AddMatchSignal(dbus.WithMatchObjectPath(objPath)); err != nil {
+		t.Fatal(err)
+	}
+
+	signals := make(chan *dbus.Signal, 32)
+	c.Signal(signals)
+
+	statuses <- player.Status{ETag: "1", State: "pause", Artist: "Low", Album: "Hey What", Track: "White Horses",
+		TrackLen: 300, Secs: 30, Volume: 40, Repeat: 2, Image: "/Artwork?service=LocalMusic&album=Hey+What"}
+
+	// properties are announced one signal at a time
+	timeout := time.After(5 * time.Second)
+	for paused := false; !paused; {
+		select {
+		case s := <-signals:
+			if s.Name != propsIface+".PropertiesChanged" {
+				continue
+			}
+
+			changed, _ := s.Body[1].(map[string]dbus.Variant)
+			paused = changed["PlaybackStatus"].Value() == "Paused"
+		case <-timeout:
+			t.Fatal("no PropertiesChanged signal for PlaybackStatus")
+		}
+	}
+
+	obj := c.Object(busName, objPath)
+
+	get := func(prop string) any {
+		t.Helper()
+
+		v, err := obj.GetProperty(playerIface + "." + prop)
+		if err != nil {
+			t.Fatalf("Get %s: %v", prop, err)
+		}
+
+		return v.Value()
+	}
+
+	meta := get("Metadata").(map[string]dbus.Variant)
+	if meta["xesam:title"].Value() != "White Horses" ||
+		meta["mpris:length"].Value() != int64(300e6) ||
+		meta["mpris:artUrl"].Value() != "http://bluesound.lan:11000/Artwork?service=LocalMusic&album=Hey+What" {
+		t.Errorf("unexpected metadata %v", meta)
+	}
+
+	if v := get("Volume"); v != 0.4 {
+		t.Errorf("Volume = %v, want 0.4", v)
+	}
+
+	if v := get("LoopStatus"); v != "None" {
+		t.Errorf("LoopStatus = %v, want None", v)
+	}
+
+	if v := get("Position"); v != int64(30e6) {
+		t.Errorf("Position = %v, want 30000000", v)
+	}
+
+	calls := []struct {
+		method string
+		args   []any
+		want   string
+	}{
+		{playerIface + ".PlayPause", nil, "playpause"},
+		{playerIface + ".Next", nil, "next"},
+		{playerIface + ".Previous", nil, "previous"},
+		{playerIface + ".Stop", nil, "stop"},
+		{playerIface + ".Seek", []any{int64(15e6)}, "seek 45"},
+		{playerIface + ".SetPosition", []any{dbus.ObjectPath(trackPrefix + "0"), int64(100e6)}, "seek 100"},
+		{propsIface + ".Set", []any{playerIface, "Volume", dbus.MakeVariant(0.25)}, "volume 25"},
+		{propsIface + ".Set", []any{playerIface, "LoopStatus", dbus.MakeVariant("Track")}, "repeat 1"},
+		{propsIface + ".Set", []any{playerIface, "Shuffle", dbus.MakeVariant(true)}, "shuffle true"},
+	}
+
+	for _, cl := range calls {
+		if err := obj.Call(cl.method, 0, cl.args...).Err; err != nil {
+			t.Fatalf("%s: %v", cl.method, err)
+		}
+
+		select {
+		case got := <-fp.calls:
+			if got != cl.want {
+				t.Errorf("%s called %q, want %q", cl.method, got, cl.want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s didn't reach the player", cl.method)
+		}
+	}
+
+	if err := obj.SetProperty(playerIface+".Metadata", dbus.MakeVariant("x")); err == nil {
+		t.Error("setting Metadata succeeded, want read-only error")
+	}
+
+	if err := obj.SetProperty(playerIface+".LoopStatus", dbus.MakeVariant("Sometimes")); err == nil {
+		t.Error("setting an unknown loop status succeeded")
+	}
+
+	var xml string
+	if err := obj.Call("org.freedesktop.DBus.Introspectable.Introspect", 0).Store(&xml); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(xml, `<method name="Seek">`) || strings.Contains(xml, "SeekBy") {
+		t.Errorf("Seek isn't introspected by its name on the bus:\n%s", xml)
+	}
+}
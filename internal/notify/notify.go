@@ -0,0 +1,145 @@
+// Package notify reports what happens in the background to the user. Messages
+// are shown on the status bar for a moment, and warnings and errors are also
+// kept on the messages page for the rest of the session.
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Level tells how important a [Message] is.
+type Level int
+
+const (
+	Info Level = iota
+	Warning
+	Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+
+	return "info"
+}
+
+// A Message is a notification shown to the user.
+type Message struct {
+	Level Level
+	Text  string
+	Time  time.Time
+}
+
+// Notifier is implemented by types that report messages to the user.
+//
+// Warn and Error log msg at their level with err and further attributes given
+// as key-value pairs in args, and show msg followed by values of the
+// attributes and err, e.g. "enqueueing album Blue: connection refused".
+type Notifier interface {
+	Info(text string)
+	Warn(msg string, err error, args ...any)
+	Error(msg string, err error, args ...any)
+}
+
+// buffered is the number of messages waiting to be shown on the status bar
+// after which new ones are only kept.
+const buffered = 16
+
+// Center is a [Notifier] delivering messages to the status bar and keeping
+// warnings and errors of the session.
+type Center struct {
+	ch chan Message
+
+	mu   sync.Mutex
+	kept []Message
+}
+
+func New() *Center {
+	return &Center{ch: make(chan Message, buffered)}
+}
+
+// Messages returns a channel delivering messages to show.
+func (c *Center) Messages() <-chan Message {
+	return c.ch
+}
+
+// Kept returns warnings and errors reported so far, the oldest first.
+func (c *Center) Kept() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Message(nil), c.kept...)
+}
+
+func (c *Center) Info(text string) {
+	slog.Info(text)
+	c.notify(Info, text)
+}
+
+func (c *Center) Warn(msg string, err error, args ...any) {
+	slog.Warn(msg, append([]any{"err", err}, args...)...)
+	c.notify(Warning, format(msg, err, args))
+}
+
+func (c *Center) Error(msg string, err error, args ...any) {
+	slog.Error(msg, append([]any{"err", err}, args...)...)
+	c.notify(Error, format(msg, err, args))
+}
+
+// notify keeps warnings and errors and sends m to the status bar, dropping it
+// there if too many are waiting, so that reporting never blocks.
+func (c *Center) notify(l Level, text string) {
+	m := Message{Level: l, Text: text, Time: time.Now()}
+
+	if l > Info {
+		c.mu.Lock()
+		c.kept = append(c.kept, m)
+		c.mu.Unlock()
+	}
+
+	select {
+	case c.ch <- m:
+	default:
+	}
+}
+
+// format returns the text of a warning or error.
+func format(msg string, err error, args []any) string {
+	var b strings.Builder
+	b.WriteString(msg)
+
+	for i := 1; i < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v", args[i])
+	}
+
+	if err != nil {
+		fmt.Fprintf(&b, ": %v", err)
+	}
+
+	return b.String()
+}
+
+// Log is a [Notifier] that only logs, for use where there's no user interface.
+var Log Notifier = logger{}
+
+type logger struct{}
+
+func (logger) Info(text string) {
+	slog.Info(text)
+}
+
+func (logger) Warn(msg string, err error, args ...any) {
+	slog.Warn(msg, append([]any{"err", err}, args...)...)
+}
+
+func (logger) Error(msg string, err error, args ...any) {
+	slog.Error(msg, append([]any{"err", err}, args...)...)
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCenter(t *testing.T) {
+	c := New()
+
+	c.Info("added to queue: Blue")
+	c.Warn("fetching next track", errors.New("timeout"))
+	c.Error("enqueueing album", errors.New("connection refused"), "album", "Blue", "artist", "Joni Mitchell")
+
+	want := []Message{
+		{Level: Info, Text: "added to queue: Blue"},
+		{Level: Warning, Text: "fetching next track: timeout"},
+		{Level: Error, Text: "enqueueing album Blue Joni Mitchell: connection refused"},
+	}
+
+	for _, w := range want {
+		m := <-c.Messages()
+		if m.Level != w.Level || m.Text != w.Text || m.Time.IsZero() {
+			t.Errorf("got %v %q, want %v %q", m.Level, m.Text, w.Level, w.Text)
+		}
+	}
+
+	if k := c.Kept(); len(k) != 2 || k[0].Level != Warning || k[1].Level != Error {
+		t.Errorf("kept %+v, want the warning and the error", k)
+	}
+}
+
+func TestCenterDoesNotBlock(t *testing.T) {
+	c := New()
+
+	// nobody reads messages, yet reporting goes on and errors are kept
+	for i := 0; i < buffered*2; i++ {
+		c.Error("fetching playlists", errors.New("timeout"))
+	}
+
+	if n := len(c.Kept()); n != buffered*2 {
+		t.Errorf("kept %d errors, want %d", n, buffered*2)
+	}
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/keymap"
+	"github.com/mkozjak/blutui/internal/theme"
+	"github.com/mkozjak/tview"
+)
+
+// PageName is the name of the page holding [Page].
+const PageName = "messages"
+
+// Page is a scrollable list of warnings and errors kept by a [Center],
+// the latest at the bottom. It catches up with new ones whenever it's drawn.
+type Page struct {
+	*tview.TextView
+	center *Center
+	keys   *keymap.Keymap
+
+	// Number of messages listed, as of the last draw
+	shown int
+}
+
+func NewPage(c *Center, k *keymap.Keymap) *Page {
+	p := &Page{
+		TextView: tview.NewTextView(),
+		center:   c,
+		keys:     k,
+		shown:    -1,
+	}
+
+	p.SetDynamicColors(true).
+		SetWrap(true).
+		SetTextColor(theme.Current.Text)
+
+	p.SetTitle(" [::b]Messages ").
+		SetBorder(true).
+		SetBorderColor(theme.Current.Border).
+		SetBackgroundColor(tcell.ColorDefault).
+		SetTitleAlign(tview.AlignLeft).
+		SetCustomBorders(internal.CustomBorders)
+
+	p.SetInputCapture(p.KeyboardHandler)
+
+	return p
+}
+
+func (p *Page) Draw(screen tcell.Screen) {
+	if m := p.center.Kept(); len(m) != p.shown {
+		p.shown = len(m)
+		p.SetText(list(m)).ScrollToEnd()
+	}
+
+	p.TextView.Draw(screen)
+}
+
+// list returns the text listing messages m.
+func list(m []Message) string {
+	if len(m) == 0 {
+		return theme.Current.Tag(theme.Current.Dim) + "No warnings or errors so far."
+	}
+
+	var b strings.Builder
+	for _, msg := range m {
+		fmt.Fprintf(&b, "%s%s %s%-7s[-] %s\n",
+			theme.Current.Tag(theme.Current.Dim), msg.Time.Format("15:04:05"),
+			LevelTag(msg.Level), msg.Level, tview.Escape(msg.Text))
+	}
+
+	return b.String()
+}
+
+// LevelTag returns a style tag colouring text of a message of level l.
+func LevelTag(l Level) string {
+	switch l {
+	case Warning:
+		return theme.Current.Tag(theme.Current.Warning)
+	case Error:
+		return theme.Current.Tag(theme.Current.Error)
+	}
+
+	return theme.Current.Tag(theme.Current.Text)
+}
+
+func (p *Page) KeyboardHandler(event *tcell.EventKey) *tcell.EventKey {
+	switch p.keys.Action(event, keymap.Navigation) {
+	case keymap.PageUp:
+		return tcell.NewEventKey(tcell.KeyPgUp, 0, tcell.ModNone)
+	case keymap.PageDown:
+		return tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone)
+	case keymap.Down:
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case keymap.Up:
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	case keymap.Top:
+		return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+	case keymap.Bottom:
+		return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+	}
+
+	return event
+}
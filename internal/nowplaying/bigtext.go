@@ -0,0 +1,90 @@
+package nowplaying
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	internal "github.com/mkozjak/blutui/internal"
+)
+
+// bigFont is a three rows tall font drawn with box-drawing characters.
+// Every row of a glyph has the same width.
+var bigFont = map[rune][3]string{
+	'A':  {"╔═╗", "╠═╣", "╩ ╩"},
+	'B':  {"╔╗ ", "╠╩╗", "╚═╝"},
+	'C':  {"╔═╗", "║  ", "╚═╝"},
+	'D':  {"╔╦╗", " ║║", "═╩╝"},
+	'E':  {"╔═╗", "║╣ ", "╚═╝"},
+	'F':  {"╔═╗", "╠╣ ", "╚  "},
+	'G':  {"╔═╗", "║ ╦", "╚═╝"},
+	'H':  {"╦ ╦", "╠═╣", "╩ ╩"},
+	'I':  {"╦", "║", "╩"},
+	'J':  {" ╦", " ║", "╚╝"},
+	'K':  {"╦╔═", "╠╩╗", "╩ ╩"},
+	'L':  {"╦  ", "║  ", "╩═╝"},
+	'M':  {"╔╦╗", "║║║", "╩ ╩"},
+	'N':  {"╔╗╔", "║║║", "╝╚╝"},
+	'O':  {"╔═╗", "║ ║", "╚═╝"},
+	'P':  {"╔═╗", "╠═╝", "╩  "},
+	'Q':  {"╔═╗ ", "║═╬╗", "╚═╝╚"},
+	'R':  {"╦═╗", "╠╦╝", "╩╚═"},
+	'S':  {"╔═╗", "╚═╗", "╚═╝"},
+	'T':  {"╔╦╗", " ║ ", " ╩ "},
+	'U':  {"╦ ╦", "║ ║", "╚═╝"},
+	'V':  {"╦  ╦", "╚╗╔╝", " ╚╝ "},
+	'W':  {"╦ ╦", "║║║", "╚╩╝"},
+	'X':  {"═╗ ╦", "╔╩╦╝", "╩ ╚═"},
+	'Y':  {"╦ ╦", "╚╦╝", " ╩ "},
+	'Z':  {"╔═╗", "╔═╝", "╚═╝"},
+	'0':  {"╔═╗", "║ ║", "╚═╝"},
+	'1':  {"╗", "║", "╩"},
+	'2':  {"╔═╗", "╔═╝", "╚══"},
+	'3':  {"╔═╗", " ═╣", "╚═╝"},
+	'4':  {"╦ ╦", "╚═╣", "  ╩"},
+	'5':  {"╔══", "╚═╗", "╚═╝"},
+	'6':  {"╔═╗", "╠═╗", "╚═╝"},
+	'7':  {"╔═╗", "  ║", "  ╩"},
+	'8':  {"╔═╗", "╠═╣", "╚═╝"},
+	'9':  {"╔═╗", "╚═╣", "╚═╝"},
+	' ':  {"  ", "  ", "  "},
+	'-':  {"  ", "══", "  "},
+	'.':  {" ", " ", "▪"},
+	',':  {" ", " ", "╛"},
+	'\'': {"╕", " ", " "},
+	'!':  {"║", "║", "▪"},
+	'?':  {"╔═╗", " ╔╝", " ▪ "},
+	'&':  {"╔╗ ", "╔╬═", "╚╝ "},
+	'/':  {"  ╔", " ╔╝", "╔╝ "},
+	'(':  {"╔", "║", "╚"},
+	')':  {"╗", "║", "╝"},
+	':':  {"▪", " ", "▪"},
+}
+
+// bigText renders s with [bigFont], returning its three rows.
+// It returns false if s contains characters the font doesn't have,
+// even after removing accents, or if it is wider than width.
+func bigText(s string, width int) ([3]string, bool) {
+	var rows [3]strings.Builder
+
+	for i, r := range strings.ToUpper(internal.RemoveAccents(s)) {
+		g, ok := bigFont[r]
+		if !ok {
+			return [3]string{}, false
+		}
+
+		for j := range rows {
+			if i > 0 {
+				rows[j].WriteRune(' ')
+			}
+
+			rows[j].WriteString(g[j])
+		}
+	}
+
+	res := [3]string{rows[0].String(), rows[1].String(), rows[2].String()}
+	if utf8.RuneCountInString(res[0]) > width {
+		return [3]string{}, false
+	}
+
+	return res, true
+}
@@ -0,0 +1,343 @@
+// Package nowplaying implements a full-screen page showing the currently
+// playing track, suitable for a spare monitor.
+package nowplaying
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/app"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/blutui/internal/theme"
+	"github.com/mkozjak/tview"
+)
+
+// PageName is the name of the page holding [NowPlaying].
+const PageName = "nowplaying"
+
+// maxBarWidth limits the width of the progress bar on wide screens.
+const maxBarWidth = 60
+
+type appManager interface {
+	app.Drawer
+	app.PageViewer
+}
+
+// Queuer fetches tracks from player's play queue.
+type Queuer interface {
+	Queue(start, end int) ([]player.QueueTrack, error)
+}
+
+// Seeker jumps to a position of the current track, in seconds.
+type Seeker interface {
+	Seek(secs int)
+}
+
+// Player is implemented by the player and used for fetching the next track
+// and seeking by clicking the progress bar.
+type Player interface {
+	Queuer
+	Seeker
+}
+
+// NowPlaying is a tview primitive that draws the currently playing track
+// along with its stream format, progress, playback modes, volume and the
+// next track in the queue. It is updated live from player's status updates.
+type NowPlaying struct {
+	*tview.Box
+	app      appManager
+	player   Player
+	notifier notify.Notifier
+
+	mu      sync.Mutex
+	status  player.Status
+	updated time.Time
+	next    *player.QueueTrack
+	nextFor int
+
+	// Position of the progress bar on screen, as of the last draw
+	bar progressBar
+}
+
+// progressBar holds the screen position of the progress bar and the length
+// of the track it shows, so that clicks on it can be turned into positions.
+type progressBar struct {
+	x, y, width int
+	total       int
+}
+
+func New(a appManager, p Player, nt notify.Notifier) *NowPlaying {
+	n := &NowPlaying{
+		Box:      tview.NewBox(),
+		app:      a,
+		player:   p,
+		notifier: nt,
+		nextFor:  -1,
+	}
+
+	n.Box.SetBackgroundColor(tcell.ColorDefault)
+	return n
+}
+
+// Listen consumes player status updates given its read-only channel. While the
+// page is shown and a track is playing, it is redrawn every second so that the
+// elapsed time and progress keep moving between status updates.
+func (n *NowPlaying) Listen(ch <-chan player.Status) {
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	for {
+		select {
+		case s, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			n.mu.Lock()
+			n.status = s
+			n.updated = time.Now()
+			fetchNext := s.Song != n.nextFor && (s.State == "play" || s.State == "pause")
+			if fetchNext {
+				n.nextFor = s.Song
+				n.next = nil
+			}
+			n.mu.Unlock()
+
+			if fetchNext {
+				go n.fetchNext(s.Song)
+			}
+
+			if n.app.CurrentPage() == PageName {
+				n.app.Draw()
+			}
+		case <-tick.C:
+			n.mu.Lock()
+			playing := n.status.State == "play" || n.status.State == "stream"
+			n.mu.Unlock()
+
+			if playing && n.app.CurrentPage() == PageName {
+				n.app.Draw()
+			}
+		}
+	}
+}
+
+// fetchNext fetches the track that follows the one at index song in the queue.
+func (n *NowPlaying) fetchNext(song int) {
+	t, err := n.player.Queue(song+1, song+1)
+	if err != nil {
+		n.notifier.Warn("fetching next track", err)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// Another track started playing in the meantime
+	if n.nextFor != song {
+		return
+	}
+
+	if len(t) > 0 {
+		n.next = &t[0]
+	}
+
+	if n.app.CurrentPage() == PageName {
+		go n.app.Draw()
+	}
+}
+
+// elapsed returns the playing position interpolated since the last status update.
+func (n *NowPlaying) elapsed() int {
+	secs := n.status.Secs
+	if n.status.State == "play" || n.status.State == "stream" {
+		secs += int(time.Since(n.updated).Seconds())
+	}
+
+	if n.status.TrackLen > 0 && secs > n.status.TrackLen {
+		secs = n.status.TrackLen
+	}
+
+	return secs
+}
+
+// Draw draws this primitive onto the screen.
+func (n *NowPlaying) Draw(screen tcell.Screen) {
+	n.Box.DrawForSubclass(screen, n)
+	x, y, width, height := n.Box.GetInnerRect()
+
+	n.mu.Lock()
+	s := n.status
+	elapsed := n.elapsed()
+	next := n.next
+	n.mu.Unlock()
+
+	track, artist, album := s.Track, s.Artist, s.Album
+	if s.State == "stream" || (track == "" && artist == "") {
+		// radio stations put their metadata into title lines
+		track, artist, album = s.Title2, s.Title3, ""
+	}
+
+	var lines []string
+
+	if track == "" {
+		lines = append(lines, "", "[::b]nothing is playing", "")
+	} else if big, ok := bigText(track, width); ok {
+		lines = append(lines, big[0], big[1], big[2])
+	} else {
+		lines = append(lines, "", "[::b]"+tview.Escape(track), "")
+	}
+
+	lines = append(lines,
+		"",
+		theme.Current.Tag(theme.Current.Accent)+"[::b]"+tview.Escape(artist),
+		"[::i]"+tview.Escape(album),
+		"",
+		theme.Current.Tag(theme.Current.Dim)+tview.Escape(streamInfo(s)))
+
+	progressLine := len(lines)
+	lines = append(lines,
+		progress(elapsed, s.TrackLen, min(width-2, maxBarWidth)),
+		"",
+		playback(s),
+		"")
+
+	if next != nil {
+		lines = append(lines, theme.Current.Tag(theme.Current.Dim)+"next:[-] "+tview.Escape(next.Artist+" - "+next.Title))
+	}
+
+	top := y + max((height-len(lines))/2, 0)
+	var bar progressBar
+
+	for i, l := range lines {
+		if top+i >= y+height {
+			break
+		}
+
+		tview.Print(screen, l, x, top+i, width, tview.AlignCenter, theme.Current.Text)
+
+		// remember where the centered progress bar ended up for seeking
+		if i == progressLine && s.TrackLen > 0 {
+			bw := min(width-2, maxBarWidth) - 12
+			if bw < 1 {
+				continue
+			}
+
+			start := x + width/2 - tview.TaggedStringWidth(l)/2
+			bar = progressBar{
+				x:     start + len(internal.FormatDuration(elapsed)) + 1,
+				y:     top + i,
+				width: bw,
+				total: s.TrackLen,
+			}
+		}
+	}
+
+	n.mu.Lock()
+	n.bar = bar
+	n.mu.Unlock()
+}
+
+// MouseHandler returns the mouse handler for this primitive. Clicking the
+// progress bar seeks to the clicked position of the current track.
+func (n *NowPlaying) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return n.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		if action != tview.MouseLeftClick {
+			return false, nil
+		}
+
+		x, y := event.Position()
+
+		n.mu.Lock()
+		b := n.bar
+		n.mu.Unlock()
+
+		if b.width < 1 || y != b.y || x < b.x || x >= b.x+b.width {
+			return false, nil
+		}
+
+		go n.player.Seek((x - b.x) * b.total / b.width)
+		return true, nil
+	})
+}
+
+// streamInfo returns stream quality, format and service, e.g. "hd FLAC · LocalMusic".
+func streamInfo(s player.Status) string {
+	var parts []string
+
+	if f := strings.TrimSpace(s.Quality + " " + s.Format); f != "" {
+		parts = append(parts, f)
+	}
+
+	if s.Service != "" {
+		parts = append(parts, s.Service)
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+// progress returns a progress bar of the given width surrounded by
+// elapsed and total time. Streams without length only show elapsed time.
+func progress(elapsed, total, width int) string {
+	if total <= 0 {
+		if elapsed <= 0 {
+			return ""
+		}
+
+		return internal.FormatDuration(elapsed)
+	}
+
+	bar := width - 12
+	if bar < 1 {
+		return internal.FormatDuration(elapsed) + " / " + internal.FormatDuration(total)
+	}
+
+	done := bar * elapsed / total
+
+	return fmt.Sprintf("%s %s%s%s%s[-] %s",
+		internal.FormatDuration(elapsed),
+		theme.Current.Tag(theme.Current.Accent),
+		strings.Repeat("━", done),
+		theme.Current.Tag(theme.Current.Dim),
+		strings.Repeat("─", bar-done),
+		internal.FormatDuration(total))
+}
+
+// playback returns playback state, repeat and shuffle modes and volume.
+func playback(s player.Status) string {
+	state := map[string]string{
+		"play":    "▶ playing",
+		"stream":  "▶ streaming",
+		"pause":   "❚❚ paused",
+		"stop":    "■ stopped",
+		"offline": "offline",
+		"ctrlerr": "player control error",
+	}[s.State]
+
+	// repeat mode 0 repeats the queue, 1 repeats a track and 2 is off
+	repeat := "off"
+	switch s.Repeat {
+	case 0:
+		repeat = "all"
+	case 1:
+		repeat = "one"
+	}
+
+	shuffle := "off"
+	if s.Shuffle == 1 {
+		shuffle = "on"
+	}
+
+	vol := fmt.Sprintf("%d", s.Volume)
+	if s.Mute == 1 {
+		vol = "muted"
+	}
+
+	dim := theme.Current.Tag(theme.Current.Dim)
+	return fmt.Sprintf("%s   %srepeat:[-] %s   %sshuffle:[-] %s   %svol:[-] %s", state, dim, repeat, dim, shuffle, dim, vol)
+}
@@ -0,0 +1,215 @@
+package player
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// requestTimeout limits requests made by [Client].
+const requestTimeout = 10 * time.Second
+
+// Client makes requests to player's HTTP API and returns their errors.
+// Unlike [Player], it doesn't poll for status updates nor report errors
+// on a channel, which makes it suitable for one-shot commands.
+type Client struct {
+	API string
+
+	// MaxVolume is the highest level [Client.SetVolume] sets, 100 by default.
+	MaxVolume int
+
+	http *http.Client
+}
+
+func NewClient(api string) *Client {
+	return &Client{
+		API:       api,
+		MaxVolume: 100,
+		http:      &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// get requests path and returns the response body.
+func (c *Client) get(path string) ([]byte, error) {
+	resp, err := c.http.Get(c.API + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", path, resp.Status)
+	}
+
+	return body, nil
+}
+
+// Command requests path, such as /Play?url=..., discarding the response.
+func (c *Client) Command(path string) error {
+	_, err := c.get(path)
+	return err
+}
+
+// Status returns player's current status.
+func (c *Client) Status() (Status, error) {
+	var s Status
+
+	body, err := c.get("/Status")
+	if err != nil {
+		return s, err
+	}
+
+	err = xml.Unmarshal(body, &s)
+	return s, err
+}
+
+func (c *Client) Playpause() error {
+	return c.Command("/Pause?toggle=1")
+}
+
+func (c *Client) Pause() error {
+	return c.Command("/Pause")
+}
+
+func (c *Client) Resume() error {
+	return c.Command("/Play")
+}
+
+func (c *Client) Stop() error {
+	return c.Command("/Stop")
+}
+
+func (c *Client) Next() error {
+	return c.Command("/Skip")
+}
+
+func (c *Client) Previous() error {
+	return c.Command("/Back")
+}
+
+// Seek jumps to the given position of the current track, in seconds.
+func (c *Client) Seek(secs int) error {
+	return c.Command(fmt.Sprintf("/Play?seek=%d", max(secs, 0)))
+}
+
+// Volume returns volume level, from 0 to 100, and whether it is muted.
+func (c *Client) Volume() (int, bool, error) {
+	body, err := c.get("/Volume")
+	if err != nil {
+		return 0, false, err
+	}
+
+	var v volume
+
+	err = xml.Unmarshal(body, &v)
+	if err != nil {
+		return 0, false, err
+	}
+
+	m, err := strconv.ParseBool(v.Muted)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return v.Value, m, nil
+}
+
+// SetVolume sets volume to the given level, clamped between 0 and [Client.MaxVolume].
+func (c *Client) SetVolume(level int) error {
+	return c.Command(fmt.Sprintf("/Volume?level=%d", min(max(level, 0), c.MaxVolume)))
+}
+
+func (c *Client) SetMute(on bool) error {
+	if on {
+		return c.Command("/Volume?mute=1")
+	}
+
+	return c.Command("/Volume?mute=0")
+}
+
+// RepeatMode returns the repeat mode, as described in [Player.ToggleRepeatMode].
+func (c *Client) RepeatMode() (int, error) {
+	body, err := c.get("/Repeat")
+	if err != nil {
+		return -1, err
+	}
+
+	var r repeat
+
+	err = xml.Unmarshal(body, &r)
+	if err != nil {
+		return -1, err
+	}
+
+	return strconv.Atoi(r.Mode)
+}
+
+func (c *Client) SetRepeatMode(mode int) error {
+	return c.Command(fmt.Sprintf("/Repeat?state=%d", mode))
+}
+
+func (c *Client) SetShuffle(on bool) error {
+	if on {
+		return c.Command("/Shuffle?state=1")
+	}
+
+	return c.Command("/Shuffle?state=0")
+}
+
+// Queue returns tracks of the play queue with ids from start to end,
+// inclusive. A negative end returns all tracks from start on.
+func (c *Client) Queue(start, end int) ([]QueueTrack, error) {
+	path := fmt.Sprintf("/Playlist?start=%d", start)
+	if end >= 0 {
+		path += fmt.Sprintf("&end=%d", end)
+	}
+
+	body, err := c.get(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var q queue
+
+	err = xml.Unmarshal(body, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks []QueueTrack
+	for _, s := range q.Songs {
+		tracks = append(tracks, QueueTrack{ID: s.ID, Title: s.Title, Artist: s.Artist, Album: s.Album})
+	}
+
+	return tracks, nil
+}
+
+// Name returns the name the player was given in its settings, e.g. "Living Room".
+func (c *Client) Name() (string, error) {
+	body, err := c.get("/SyncStatus")
+	if err != nil {
+		return "", err
+	}
+
+	var s struct {
+		Name string `xml:"name,attr"`
+	}
+
+	err = xml.Unmarshal(body, &s)
+	return s.Name, err
+}
+
+// Reindex has the player rescan its library, e.g. after music was added to
+// a network share. The rescan runs in the background, which is told by
+// [Status.Indexing].
+func (c *Client) Reindex() error {
+	return c.Command("/Reindex")
+}
@@ -5,15 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"strconv"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 
-	"github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/notify"
 	"github.com/mkozjak/blutui/spinner"
 )
 
@@ -42,6 +41,30 @@ type Status struct {
 	Secs     int    `xml:"secs"`
 	State    string `xml:"state"`
 	Repeat   int    `xml:"repeat"`
+	Shuffle  int    `xml:"shuffle"`
+	Mute     int    `xml:"mute"`
+	Song     int    `xml:"song"`
+	Image    string `xml:"image"`
+	File     string `xml:"fn"`       // file of a local track playing
+	Indexing int    `xml:"indexing"` // 1 while the library is rescanned
+}
+
+// Used for parsing play queue tracks from /Playlist
+type queue struct {
+	Songs []struct {
+		ID     int    `xml:"id,attr"`
+		Title  string `xml:"title"`
+		Artist string `xml:"art"`
+		Album  string `xml:"alb"`
+	} `xml:"song"`
+}
+
+// A QueueTrack represents a single track in player's play queue.
+type QueueTrack struct {
+	ID     int
+	Title  string
+	Artist string
+	Album  string
 }
 
 type Controller interface {
@@ -50,320 +73,339 @@ type Controller interface {
 	Stop()
 	Next()
 	Previous()
-	VolumeHold(bool)
+	VolumeUp(big bool)
+	VolumeDown(big bool)
+	SetVolume(level int)
+	Status() Status
 	ToggleMute()
 	ToggleRepeatMode()
 	State() string
+	Online() bool
 }
 
 type Player struct {
-	API               string
-	Updates           chan<- Status
-	spinner           spinner.StartStopper
-	status            Status
-	volumeHoldCount   int
-	volumeHoldBlocker bool
-	volumeHoldTicker  *time.Ticker
-	volumeHoldMutex   sync.Mutex
+	API              string
+	Updates          chan<- Status
+	client           *Client
+	poller           *http.Client
+	spinner          spinner.StartStopper
+	notifier         notify.Notifier
+	status           Status
+	statusMutex      sync.Mutex
+	subscribers      []chan Status
+	subscribersMutex sync.Mutex
+	name             string
+	nameMutex        sync.Mutex
+
+	// Whether the player couldn't be reached when it was last polled
+	offline atomic.Bool
+
+	// The following fields track volume changes, see [Player.SetVolume].
+	volumeConfig  VolumeConfig
+	volumeMutex   sync.Mutex
+	volume        int  // last known level
+	volumeTarget  int  // level being set
+	volumeSending bool // whether a volume request is in flight
 }
 
-func New(api string, sp spinner.StartStopper, s chan<- Status) *Player {
+// New returns a new [Player] given player's API address, a spinner shown
+// while commands run, a notifier failed commands are reported to, a channel
+// receiving status updates and volume settings.
+func New(api string, sp spinner.StartStopper, n notify.Notifier, s chan<- Status, vc VolumeConfig) *Player {
+	c := NewClient(api)
+	c.MaxVolume = vc.Max
+
 	return &Player{
-		API:     api,
-		Updates: s,
-		spinner: sp,
+		API:          api,
+		Updates:      s,
+		client:       c,
+		poller:       &http.Client{Timeout: pollTimeout},
+		spinner:      sp,
+		notifier:     n,
+		volumeConfig: vc,
 	}
 }
 
-func (p *Player) State() string {
-	return p.status.State
+// Online reports whether the player could be reached when it was last polled.
+func (p *Player) Online() bool {
+	return !p.offline.Load()
 }
 
-func (p *Player) Play(url string) {
-	go p.spinner.Start()
-	_, err := http.Get(p.API + url)
-	if err != nil {
-		internal.Log("Error autoplaying track:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
-	p.spinner.Stop()
+// SetOffline marks the player as unreachable until it's polled successfully,
+// e.g. if it can't be reached at startup.
+func (p *Player) SetOffline() {
+	p.offline.Store(true)
 }
 
-func (p *Player) Playpause() {
-	go p.spinner.Start()
-	_, err := http.Get(p.API + "/Pause?toggle=1")
-	if err != nil {
-		internal.Log("Error toggling play/pause:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
-	p.spinner.Stop()
+// reportOffline tells the user that the player can't be reached, if so.
+func (p *Player) reportOffline() bool {
+	return ReportOffline(p, p.notifier)
 }
 
-func (p *Player) Stop() {
-	go p.spinner.Start()
-	_, err := http.Get(p.API + "/Stop")
-	if err != nil {
-		internal.Log("Error stopping playback:", err)
-		p.Updates <- Status{State: "ctrlerr"}
+// ReportOffline tells the user through n that player p can't be reached, if
+// so, for commands that can't be run without it.
+func ReportOffline(p interface{ Online() bool }, n notify.Notifier) bool {
+	if p.Online() {
+		return false
 	}
-	p.spinner.Stop()
-}
 
-func (p *Player) Next() {
-	go p.spinner.Start()
-	_, err := http.Get(p.API + "/Skip")
-	if err != nil {
-		internal.Log("Error switching to next track:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
-	p.spinner.Stop()
+	n.Info("player is offline")
+	return true
 }
 
-func (p *Player) Previous() {
-	go p.spinner.Start()
-	_, err := http.Get(p.API + "/Back")
-	if err != nil {
-		internal.Log("Error switching to previous track:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
-	p.spinner.Stop()
+func (p *Player) State() string {
+	return p.Status().State
 }
 
-func (p *Player) volumeUp(bigstep bool) {
-	var step int
-	if bigstep == true {
-		step = 10
-	} else {
-		step = 3
-	}
-
-	v, _, err := p.currentVolume()
-	if err != nil {
-		internal.Log("Error fetching volume state:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
+// Status returns the most recently received player status.
+func (p *Player) Status() Status {
+	p.statusMutex.Lock()
+	defer p.statusMutex.Unlock()
 
-	_, err = http.Get(fmt.Sprintf("%s/Volume?level=%d", p.API, v+step))
-	if err != nil {
-		internal.Log("Error setting volume up:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
+	return p.status
 }
 
-func (p *Player) volumeDown(bigstep bool) {
-	var step int
-	if bigstep == true {
-		step = 10
-	} else {
-		step = 3
-	}
+// Subscribe returns a read-only channel that receives every player status
+// fetched by [Player.PollStatus], in addition to [Player.Updates].
+// Updates are dropped for subscribers that don't keep up with them.
+func (p *Player) Subscribe() <-chan Status {
+	ch := make(chan Status, 16)
 
-	v, _, err := p.currentVolume()
-	if err != nil {
-		internal.Log("Error fetching volume state:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
+	p.subscribersMutex.Lock()
+	p.subscribers = append(p.subscribers, ch)
+	p.subscribersMutex.Unlock()
 
-	_, err = http.Get(fmt.Sprintf("%s/Volume?level=%d", p.API, v-step))
-	if err != nil {
-		internal.Log("Error setting volume down:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
+	return ch
 }
 
-func (p *Player) VolumeHold(up bool) {
-	if p.volumeHoldBlocker == true {
-		return
-	}
+// Unsubscribe stops sending statuses to ch, a channel returned by
+// [Player.Subscribe], and closes it.
+func (p *Player) Unsubscribe(ch <-chan Status) {
+	p.subscribersMutex.Lock()
+	defer p.subscribersMutex.Unlock()
 
-	p.volumeHoldCount = p.volumeHoldCount + 1
+	for i, s := range p.subscribers {
+		if s == ch {
+			p.subscribers = append(p.subscribers[:i], p.subscribers[i+1:]...)
+			close(s)
+			return
+		}
+	}
+}
 
-	if p.volumeHoldTicker != nil {
-		return
+// publish sends s to [Player.Updates] and all subscribers. While volume is
+// being changed, s holds the level being set rather than a stale one.
+func (p *Player) publish(s Status) {
+	p.volumeMutex.Lock()
+	if p.volumeSending {
+		s.Volume = p.volumeTarget
+	} else if s.State != "offline" && s.State != "ctrlerr" {
+		p.volume = s.Volume
 	}
+	p.volumeMutex.Unlock()
 
-	p.volumeHoldTicker = time.NewTicker(time.Second)
-	done := make(chan bool)
+	p.statusMutex.Lock()
+	p.status = s
+	p.statusMutex.Unlock()
 
-	go func() {
-		time.Sleep(500 * time.Millisecond)
-		done <- true
-	}()
+	p.Updates <- s
 
-	go p.spinner.Start()
+	p.subscribersMutex.Lock()
+	defer p.subscribersMutex.Unlock()
 
-	for {
+	for _, ch := range p.subscribers {
 		select {
-		case <-done:
-			p.volumeHoldTicker.Stop()
-			p.volumeHoldTicker = nil
-
-			close(done)
-
-			if p.volumeHoldCount < 5 {
-				if up == true {
-					go p.volumeUp(false)
-				} else {
-					go p.volumeDown(false)
-				}
-			} else {
-				if up == true {
-					go p.volumeUp(true)
-				} else {
-					go p.volumeDown(true)
-				}
-
-				p.volumeHoldBlocker = true
-				p.volumeHoldMutex.Lock()
-				time.Sleep(5 * time.Second)
-				p.volumeHoldBlocker = false
-				p.volumeHoldMutex.Unlock()
-			}
-
-			p.volumeHoldCount = 0
-			p.spinner.Stop()
-			return
+		case ch <- s:
+		default:
 		}
 	}
 }
 
-func (p *Player) ToggleMute() {
-	go p.spinner.Start()
-	_, m, err := p.currentVolume()
-	if err != nil {
-		internal.Log("Error getting mute state:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
+// Name returns the name of the player, fetching it once it's first asked for.
+func (p *Player) Name() string {
+	p.nameMutex.Lock()
+	defer p.nameMutex.Unlock()
 
-	if m == false {
-		_, err = http.Get(p.API + "/Volume?mute=1")
-	} else {
-		_, err = http.Get(p.API + "/Volume?mute=0")
-	}
-	if err != nil {
-		internal.Log("Error toggling mute state:", err)
-		p.Updates <- Status{State: "ctrlerr"}
+	if p.name == "" {
+		n, err := p.client.Name()
+		if err != nil {
+			p.notifier.Warn("fetching player name", err)
+		}
+
+		p.name = n
 	}
-	go p.spinner.Stop()
+
+	return p.name
 }
 
-// ToggleRepeatMode cycles between repeat modes in ascending order
-// based on player's current repeat mode. Mode is either 0, 1 or 2.
-// 0 means repeat play queue, 1 means repeat a track, and 2 means repeat off.
-func (p *Player) ToggleRepeatMode() {
-	go p.spinner.Start()
-	r, err := p.currentRepeatMode()
-	if err != nil {
-		internal.Log("Error getting current repeat mode:", err)
-		p.Updates <- Status{State: "ctrlerr"}
-	}
+// Queue returns tracks of the play queue with ids from start to end, inclusive.
+func (p *Player) Queue(start, end int) ([]QueueTrack, error) {
+	return p.client.Queue(start, end)
+}
 
-	switch r % 3 {
-	case 0:
-		_, err = http.Get(p.API + "/Repeat?state=1")
-	case 1:
-		_, err = http.Get(p.API + "/Repeat?state=2")
-	case 2:
-		_, err = http.Get(p.API + "/Repeat?state=0")
+// do runs a player command while showing the spinner. Failed commands are
+// reported with desc and as a ctrlerr status. Commands aren't run while the
+// player is offline.
+func (p *Player) do(desc string, cmd func() error) {
+	if p.reportOffline() {
+		return
 	}
-	if err != nil {
-		internal.Log("Error toggling repeat mode:", err)
+
+	go p.spinner.Start()
+	if err := cmd(); err != nil {
+		p.notifier.Error(desc, err)
 		p.Updates <- Status{State: "ctrlerr"}
 	}
-	go p.spinner.Stop()
+	p.spinner.Stop()
 }
 
-func (p *Player) currentRepeatMode() (int, error) {
-	resp, err := http.Get(p.API + "/Repeat")
-	if err != nil {
-		return -1, err
-	}
-	defer resp.Body.Close()
+func (p *Player) Play(url string) {
+	p.do("autoplaying track", func() error { return p.client.Command(url) })
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return -1, err
-	}
+func (p *Player) Playpause() {
+	p.do("toggling play/pause", p.client.Playpause)
+}
 
-	var repeatRes repeat
+func (p *Player) Stop() {
+	p.do("stopping playback", p.client.Stop)
+}
 
-	err = xml.Unmarshal(body, &repeatRes)
-	if err != nil {
-		return -1, err
-	}
+func (p *Player) Next() {
+	p.do("switching to next track", p.client.Next)
+}
 
-	m, err := strconv.Atoi(repeatRes.Mode)
-	if err != nil {
-		return -1, err
-	}
+func (p *Player) Previous() {
+	p.do("switching to previous track", p.client.Previous)
+}
 
-	return m, nil
+// Pause pauses playback. Unlike [Player.Playpause], it never resumes it.
+func (p *Player) Pause() {
+	p.do("pausing playback", p.client.Pause)
 }
 
-func (p *Player) currentVolume() (int, bool, error) {
-	resp, err := http.Get(p.API + "/Volume")
-	if err != nil {
-		return 0, false, err
-	}
-	defer resp.Body.Close()
+// Resume starts or resumes playback of the current play queue.
+func (p *Player) Resume() {
+	p.do("resuming playback", p.client.Resume)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, false, err
-	}
+// Seek jumps to the given position of the current track, in seconds.
+func (p *Player) Seek(secs int) {
+	p.do("seeking", func() error { return p.client.Seek(secs) })
+}
 
-	var volRes volume
+// SetRepeatMode sets repeat mode, as described in [Player.ToggleRepeatMode].
+func (p *Player) SetRepeatMode(mode int) {
+	p.do("setting repeat mode", func() error { return p.client.SetRepeatMode(mode) })
+}
 
-	err = xml.Unmarshal(body, &volRes)
-	if err != nil {
-		return 0, false, err
-	}
+// SetShuffle turns shuffling of the play queue on or off.
+func (p *Player) SetShuffle(on bool) {
+	p.do("setting shuffle", func() error { return p.client.SetShuffle(on) })
+}
 
-	m, err := strconv.ParseBool(volRes.Muted)
-	if err != nil {
-		return 0, false, err
-	}
+func (p *Player) ToggleMute() {
+	p.do("toggling mute state", func() error {
+		_, m, err := p.client.Volume()
+		if err != nil {
+			return err
+		}
+
+		return p.client.SetMute(!m)
+	})
+}
+
+// ToggleRepeatMode cycles between repeat modes in ascending order
+// based on player's current repeat mode. Mode is either 0, 1 or 2.
+// 0 means repeat play queue, 1 means repeat a track, and 2 means repeat off.
+func (p *Player) ToggleRepeatMode() {
+	p.do("toggling repeat mode", func() error {
+		r, err := p.client.RepeatMode()
+		if err != nil {
+			return err
+		}
 
-	return volRes.Value, m, nil
+		return p.client.SetRepeatMode((r + 1) % 3)
+	})
 }
 
+const (
+	// pollInterval is the time between status requests.
+	pollInterval = 5 * time.Second
+
+	// pollTimeout limits status requests, which the player holds for up to
+	// a minute if nothing changes.
+	pollTimeout = 70 * time.Second
+
+	// reconnectDelay is the time before an offline player is tried again,
+	// doubled after every attempt up to maxReconnectDelay.
+	reconnectDelay    = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// PollStatus long-polls the player for status updates until the app quits.
+// If the player can't be reached, an offline status is published and it's
+// tried again after a delay that grows up to [maxReconnectDelay], so that
+// normal operation resumes once it's back.
 func (p *Player) PollStatus() {
 	etag := ""
+	delay := reconnectDelay
 
 	for {
-		resp, err := http.Get(p.API + "/Status?timeout=60" + etag)
-		if err != nil {
-			uerr := url.Error{Err: err}
-			var derr *net.DNSError
-
-			if errors.As(err, &derr) || errors.Is(err, syscall.ECONNREFUSED) {
-				s := Status{State: "neterr"}
+		s, err := p.pollStatus(etag)
 
-				p.status = s
-				p.Updates <- s
-				continue
+		var uerr *url.Error
+		switch {
+		case errors.As(err, &uerr):
+			if !p.offline.Swap(true) {
+				p.notifier.Warn("player went offline", err)
 			}
 
-			if uerr.Timeout() {
-				continue
+			if p.State() != "offline" {
+				p.publish(Status{State: "offline"})
 			}
-		}
-		defer resp.Body.Close()
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
+			etag = ""
+			time.Sleep(delay)
+			delay = min(delay*2, maxReconnectDelay)
+			continue
+		case err != nil:
+			slog.Warn("polling status", "err", err)
+			time.Sleep(pollInterval)
 			continue
 		}
 
-		var s Status
-		err = xml.Unmarshal(body, &s)
-		if err != nil {
-			continue
+		if p.offline.Swap(false) {
+			p.notifier.Info("player is back online")
 		}
 
-		p.status = s
-		p.Updates <- s
+		p.publish(s)
 		etag = "&etag=" + s.ETag
-		time.Sleep(5 * time.Second)
+		delay = reconnectDelay
+		time.Sleep(pollInterval)
+	}
+}
+
+// pollStatus waits for a status that differs from the one with etag, if set,
+// for up to a minute.
+func (p *Player) pollStatus(etag string) (Status, error) {
+	var s Status
+
+	resp, err := p.poller.Get(p.API + "/Status?timeout=60" + etag)
+	if err != nil {
+		return s, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return s, err
 	}
+
+	if resp.StatusCode != http.StatusOK {
+		return s, fmt.Errorf("/Status: %s", resp.Status)
+	}
+
+	err = xml.Unmarshal(body, &s)
+	return s, err
 }
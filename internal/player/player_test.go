@@ -0,0 +1,84 @@
+package player
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recorder is a notifier keeping what it's told.
+type recorder struct {
+	mu    sync.Mutex
+	infos []string
+	warns []string
+}
+
+func (r *recorder) Info(text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.infos = append(r.infos, text)
+}
+
+func (r *recorder) Warn(msg string, err error, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.warns = append(r.warns, msg)
+}
+
+func (r *recorder) Error(msg string, err error, args ...any) {}
+
+func TestOfflineCommands(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	n := &recorder{}
+	p := New(srv.URL, nopSpinner{}, n, make(chan Status, 16), VolumeConfig{Step: 2, BigStep: 10})
+	p.SetOffline()
+
+	p.Playpause()
+	p.VolumeUp(false)
+
+	if requests.Load() != 0 {
+		t.Errorf("sent %d requests while offline", requests.Load())
+	}
+
+	if len(n.infos) != 2 || n.infos[0] != "player is offline" {
+		t.Errorf("notified %q, want player is offline twice", n.infos)
+	}
+}
+
+func TestPollStatusOffline(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	n := &recorder{}
+	updates := make(chan Status, 16)
+	p := New(srv.URL, nopSpinner{}, n, updates, VolumeConfig{})
+
+	go p.PollStatus()
+
+	select {
+	case s := <-updates:
+		if s.State != "offline" {
+			t.Errorf("published state %q, want offline", s.State)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("offline status wasn't published")
+	}
+
+	if p.Online() {
+		t.Error("player is online after failing to reach it")
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.warns) != 1 || n.warns[0] != "player went offline" {
+		t.Errorf("warned %q, want player went offline", n.warns)
+	}
+}
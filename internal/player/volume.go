@@ -0,0 +1,179 @@
+package player
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// VolumeFile is the name of a file in the config directory that adjusts
+// volume control, e.g.
+//
+//	{"step": 2, "bigStep": 10, "max": 60}
+const VolumeFile = "volume.json"
+
+// VolumeConfig holds volume control settings.
+type VolumeConfig struct {
+	// Step and BigStep are levels by which volume up and down change volume.
+	Step    int `json:"step"`
+	BigStep int `json:"bigStep"`
+
+	// Max is the highest level volume can be set to, so that it can't be
+	// turned up to 100 by accident.
+	Max int `json:"max"`
+}
+
+// DefaultVolumeConfig returns volume settings used if the [VolumeFile] is missing.
+func DefaultVolumeConfig() VolumeConfig {
+	return VolumeConfig{Step: 2, BigStep: 10, Max: 100}
+}
+
+// LoadVolumeConfig reads volume settings from the [VolumeFile] at path.
+// Settings missing in the file keep their defaults.
+func LoadVolumeConfig(path string) (VolumeConfig, error) {
+	c := DefaultVolumeConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c, nil
+		}
+
+		return c, err
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for name, v := range map[string]int{"step": c.Step, "bigStep": c.BigStep, "max": c.Max} {
+		if v < 1 || v > 100 {
+			return c, fmt.Errorf("%s: %s: %d is not between 1 and 100", path, name, v)
+		}
+	}
+
+	return c, nil
+}
+
+// ParseVolume parses a volume level, such as "40", or a change of the current
+// level, such as "+5" or "-5", and returns the resulting level.
+func ParseVolume(s string, current int) (int, error) {
+	s = strings.TrimSpace(s)
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid volume %q", s)
+	}
+
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		n += current
+	}
+
+	return n, nil
+}
+
+// MaxVolume returns the highest level volume can be set to.
+func (p *Player) MaxVolume() int {
+	return p.volumeConfig.Max
+}
+
+// VolumeUp turns volume up by a step, or a big step if big is set.
+func (p *Player) VolumeUp(big bool) {
+	p.ChangeVolume(p.step(big))
+}
+
+// VolumeDown turns volume down by a step, or a big step if big is set.
+func (p *Player) VolumeDown(big bool) {
+	p.ChangeVolume(-p.step(big))
+}
+
+func (p *Player) step(big bool) int {
+	if big {
+		return p.volumeConfig.BigStep
+	}
+
+	return p.volumeConfig.Step
+}
+
+// ChangeVolume changes volume by delta, relative to the level that is being
+// set if a change is still in progress, so that quick changes add up.
+func (p *Player) ChangeVolume(delta int) {
+	p.setVolume(func(current int) int { return current + delta })
+}
+
+// SetVolume sets volume to the given level, from 0 to [Player.MaxVolume].
+func (p *Player) SetVolume(level int) {
+	p.setVolume(func(int) int { return level })
+}
+
+// setVolume sets volume to the level returned by level given the current one.
+// The new level is sent to [Player.Updates] right away, without waiting for
+// the player to report it. Changes made while a request is in flight are
+// coalesced into a single request for the latest level.
+func (p *Player) setVolume(level func(current int) int) {
+	if p.reportOffline() {
+		return
+	}
+
+	p.volumeMutex.Lock()
+
+	current := p.volume
+	if p.volumeSending {
+		current = p.volumeTarget
+	}
+
+	p.volumeTarget = min(max(level(current), 0), p.volumeConfig.Max)
+	sending := p.volumeSending
+	p.volumeSending = true
+	p.volumeMutex.Unlock()
+
+	// the latest target is shown even if another change overtook this one
+	s := p.Status()
+	p.volumeMutex.Lock()
+	s.Volume = p.volumeTarget
+	p.volumeMutex.Unlock()
+
+	p.Updates <- s
+
+	if !sending {
+		p.sendVolume()
+	}
+}
+
+// sendVolume sets volume to the target level until it stops changing.
+func (p *Player) sendVolume() {
+	go p.spinner.Start()
+	defer p.spinner.Stop()
+
+	for {
+		p.volumeMutex.Lock()
+		target := p.volumeTarget
+		p.volumeMutex.Unlock()
+
+		err := p.client.SetVolume(target)
+
+		p.volumeMutex.Lock()
+		done := err != nil || target == p.volumeTarget
+		if err == nil {
+			p.volume = target
+		}
+
+		if done {
+			p.volumeSending = false
+		}
+		p.volumeMutex.Unlock()
+
+		if err != nil {
+			p.notifier.Error("setting volume", err)
+			p.Updates <- Status{State: "ctrlerr"}
+		}
+
+		if done {
+			return
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package player
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkozjak/blutui/internal/notify"
+)
+
+type nopSpinner struct{}
+
+func (nopSpinner) Start() {}
+func (nopSpinner) Stop()  {}
+
+func TestSetVolumeCoalesces(t *testing.T) {
+	var mu sync.Mutex
+	var levels []string
+
+	first := make(chan struct{})
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		levels = append(levels, r.URL.Query().Get("level"))
+		n := len(levels)
+		mu.Unlock()
+
+		// hold the first request so that further changes pile up
+		if n == 1 {
+			close(first)
+			<-release
+		}
+
+		w.Write([]byte(`<volume>0</volume>`))
+	}))
+	defer srv.Close()
+
+	updates := make(chan Status, 16)
+	p := New(srv.URL, nopSpinner{}, notify.Log, updates, VolumeConfig{Step: 2, BigStep: 10, Max: 50})
+	p.volume = 40
+
+	done := make(chan struct{})
+	go func() {
+		p.VolumeUp(false)
+		close(done)
+	}()
+
+	<-first
+	p.VolumeUp(false)
+	p.VolumeUp(true)
+	p.VolumeDown(false)
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("volume requests didn't finish")
+	}
+
+	// 42 was in flight while 44, 54 capped to 50, and 48 were coalesced
+	if len(levels) != 2 || levels[0] != "42" || levels[1] != "48" {
+		t.Errorf("sent levels %v, want [42 48]", levels)
+	}
+
+	var shown []int
+	for len(updates) > 0 {
+		shown = append(shown, (<-updates).Volume)
+	}
+
+	if want := []int{42, 44, 50, 48}; !slices.Equal(shown, want) {
+		t.Errorf("shown volumes %v, want %v", shown, want)
+	}
+
+	if p.volume != 48 || p.volumeSending {
+		t.Errorf("volume %d, sending %v after requests finished", p.volume, p.volumeSending)
+	}
+}
+
+func TestLoadVolumeConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := LoadVolumeConfig(filepath.Join(dir, VolumeFile))
+	if err != nil || c != DefaultVolumeConfig() {
+		t.Errorf("missing file: got %+v, %v", c, err)
+	}
+
+	path := filepath.Join(dir, VolumeFile)
+	os.WriteFile(path, []byte(`{"max": 60}`), 0644)
+
+	c, err = LoadVolumeConfig(path)
+	if err != nil || c.Max != 60 || c.Step != DefaultVolumeConfig().Step {
+		t.Errorf("got %+v, %v", c, err)
+	}
+
+	for _, data := range []string{`{"max": 0}`, `{"step": 101}`, `{"bigStep": "big"}`} {
+		os.WriteFile(path, []byte(data), 0644)
+
+		if _, err := LoadVolumeConfig(path); err == nil {
+			t.Errorf("%s should fail", data)
+		}
+	}
+}
+
+func TestParseVolume(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"30", 30},
+		{"+5", 45},
+		{"-50", -10},
+		{" 7 ", 7},
+	}
+
+	for _, tt := range tests {
+		if got, err := ParseVolume(tt.in, 40); err != nil || got != tt.want {
+			t.Errorf("ParseVolume(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+
+	if _, err := ParseVolume("loud", 40); err == nil {
+		t.Error("ParseVolume(loud) should fail")
+	}
+}
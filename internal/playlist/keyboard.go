@@ -0,0 +1,50 @@
+package playlist
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mkozjak/blutui/internal/keymap"
+)
+
+func (p *Playlists) KeyboardHandler(event *tcell.EventKey) *tcell.EventKey {
+	switch p.keys.Action(event, keymap.Navigation, keymap.Playlists) {
+	case keymap.SwitchPane:
+		if p.listPane.HasFocus() {
+			if p.tracksPane.GetRowCount() == 0 {
+				return nil
+			}
+
+			p.tracksPane.Select(0, 0)
+			p.app.SetFocus(p.tracksPane)
+		} else {
+			p.tracksPane.ScrollToBeginning()
+			p.app.SetFocus(p.listPane)
+		}
+
+		return nil
+	case keymap.Select:
+		return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+	case keymap.PageUp:
+		return tcell.NewEventKey(tcell.KeyPgUp, 0, tcell.ModNone)
+	case keymap.PageDown:
+		return tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone)
+	case keymap.Down:
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case keymap.Up:
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	case keymap.Top:
+		return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+	case keymap.Bottom:
+		return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+	case keymap.AppendPlaylist:
+		p.appendToQueue()
+		return nil
+	case keymap.RenamePlaylist:
+		p.rename()
+		return nil
+	case keymap.DeletePlaylist:
+		p.remove()
+		return nil
+	}
+
+	return event
+}
@@ -0,0 +1,478 @@
+package playlist
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/app"
+	"github.com/mkozjak/blutui/internal/keymap"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/blutui/internal/theme"
+	"github.com/mkozjak/blutui/spinner"
+	"github.com/mkozjak/tview"
+)
+
+// Used for parsing data from /Playlists
+type playlists struct {
+	Names []struct {
+		Name   string `xml:",chardata"`
+		Tracks int    `xml:"tracks,attr"`
+	} `xml:"name"`
+}
+
+// Used for parsing playlist tracks from /Songs
+type songs struct {
+	Song []struct {
+		Title  string `xml:"title"`
+		Artist string `xml:"art"`
+		Album  string `xml:"alb"`
+	} `xml:"song"`
+}
+
+type track struct {
+	title  string
+	artist string
+	album  string
+}
+
+type playlist struct {
+	name  string
+	count int
+}
+
+// cached holds tracks of a playlist as fetched when it had count tracks.
+type cached struct {
+	count  int
+	tracks []track
+}
+
+// Command is implemented by [Playlists] and is used by the global keyboard
+// handler to save the current play queue.
+type Command interface {
+	SaveQueue(name string)
+}
+
+// Prompter asks the user for a single line of input on the bottom bar.
+type Prompter interface {
+	Prompt(label, text string, done func(input string))
+}
+
+type appManager interface {
+	app.Focuser
+	app.Updater
+}
+
+// Playlists represents a page that lists playlists saved on the player and
+// provides playing, enqueueing, renaming and deleting them.
+type Playlists struct {
+	container *tview.Flex
+	app       appManager
+	player    player.Controller
+	spinner   spinner.StartStopper
+	prompter  Prompter
+	notifier  notify.Notifier
+	keys      *keymap.Keymap
+	API       string
+
+	listPane   *tview.Table
+	tracksPane *tview.Table
+	playlists  []playlist
+
+	// Tracks of playlists fetched once they were selected, by name
+	cacheMu sync.Mutex
+	cache   map[string]cached
+}
+
+func New(api string, a appManager, p player.Controller, sp spinner.StartStopper, pr Prompter,
+	n notify.Notifier, k *keymap.Keymap) *Playlists {
+	return &Playlists{
+		app:      a,
+		player:   p,
+		spinner:  sp,
+		prompter: pr,
+		notifier: n,
+		keys:     k,
+		API:      api,
+		cache:    map[string]cached{},
+	}
+}
+
+func (p *Playlists) CreateContainer() *tview.Flex {
+	p.listPane = p.createListContainer()
+	p.tracksPane = p.createTracksContainer()
+
+	p.container = tview.NewFlex().SetDirection(tview.FlexRow).
+		// left and right pane
+		AddItem(tview.NewFlex().
+			AddItem(p.listPane, 0, 1, true).
+			AddItem(p.tracksPane, 0, 2, false), 0, 1, true)
+
+	p.container.SetInputCapture(p.KeyboardHandler)
+
+	return p.container
+}
+
+// left pane - playlists
+func (p *Playlists) createListContainer() *tview.Table {
+	c := tview.NewTable().
+		SetSelectable(true, false).
+		SetSelectedStyle(theme.Current.SelectedStyle(true))
+
+	c.SetTitle(" [::b]Playlist ").
+		SetBorder(true).
+		SetBorderColor(theme.Current.Border).
+		SetBackgroundColor(tcell.ColorDefault).
+		SetTitleAlign(tview.AlignLeft).
+		SetCustomBorders(internal.CustomBorders).
+		SetFocusFunc(func() {
+			c.SetSelectedStyle(theme.Current.SelectedStyle(true))
+		}).
+		SetBlurFunc(func() {
+			p.app.SetPrevFocused("playlists")
+			c.SetSelectedStyle(theme.Current.SelectedStyle(false))
+		})
+
+	c.SetSelectionChangedFunc(func(row, _ int) {
+		p.drawTracks(row)
+	})
+
+	// play the whole playlist
+	c.SetSelectedFunc(func(row, _ int) {
+		p.play(row, 0)
+	})
+
+	c.SetMouseCapture(doubleClickHandler(c, func(row int) {
+		p.play(row, 0)
+	}))
+
+	return c
+}
+
+// right pane - tracks of the selected playlist
+func (p *Playlists) createTracksContainer() *tview.Table {
+	c := tview.NewTable().
+		SetSelectable(false, false).
+		SetSelectedStyle(theme.Current.SelectedStyle(true))
+
+	c.SetTitle(" [::b]Track ").
+		SetBorder(true).
+		SetBorderColor(theme.Current.Border).
+		SetBackgroundColor(tcell.ColorDefault).
+		SetTitleAlign(tview.AlignLeft).
+		SetCustomBorders(internal.CustomBorders).
+		SetFocusFunc(func() {
+			c.SetSelectable(true, false)
+		}).
+		SetBlurFunc(func() {
+			c.SetSelectable(false, false)
+			p.app.SetPrevFocused("playlists")
+		})
+
+	// play the playlist starting from the selected track
+	c.SetSelectedFunc(func(row, _ int) {
+		pl, _ := p.listPane.GetSelection()
+		p.play(pl, row)
+	})
+
+	c.SetMouseCapture(doubleClickHandler(c, func(row int) {
+		pl, _ := p.listPane.GetSelection()
+		p.play(pl, row)
+	}))
+
+	return c
+}
+
+// doubleClickHandler returns a mouse handler that calls f with a row
+// of table c that was double-clicked.
+func doubleClickHandler(c *tview.Table, f func(row int)) func(tview.MouseAction, *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	return func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if action != tview.MouseLeftDoubleClick || !c.InRect(event.Position()) {
+			return action, event
+		}
+
+		row, _ := c.CellAt(event.Position())
+		if row >= 0 && row < c.GetRowCount() {
+			f(row)
+		}
+
+		return tview.MouseConsumed, nil
+	}
+}
+
+// FetchData fetches all saved playlists and redraws the page once done.
+// Tracks of a playlist are only fetched once it's selected.
+func (p *Playlists) FetchData() {
+	go p.spinner.Start()
+	defer p.spinner.Stop()
+
+	body, err := p.get("/Playlists")
+	if err != nil {
+		p.notifier.Error("fetching playlists", err)
+		return
+	}
+
+	var res playlists
+
+	err = xml.Unmarshal(body, &res)
+	if err != nil {
+		slog.Debug("playlists XML", "body", string(body))
+		p.notifier.Error("parsing the playlists XML", err)
+		return
+	}
+
+	var pls []playlist
+	for _, n := range res.Names {
+		pls = append(pls, playlist{name: n.Name, count: n.Tracks})
+	}
+
+	p.app.QueueUpdateDraw(func() {
+		p.playlists = pls
+		p.drawList()
+	})
+}
+
+// cachedTracks returns tracks of pl if they were fetched since it last
+// changed, as told by its number of tracks.
+func (p *Playlists) cachedTracks(pl playlist) ([]track, bool) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	c, ok := p.cache[pl.name]
+	if !ok || c.count != pl.count {
+		return nil, false
+	}
+
+	return c.tracks, true
+}
+
+// forget drops cached tracks of the playlist named name.
+func (p *Playlists) forget(name string) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	delete(p.cache, name)
+}
+
+// loadTracks fetches tracks of pl and shows them if it's still selected.
+func (p *Playlists) loadTracks(pl playlist) {
+	tracks, err := p.fetchTracks(pl.name)
+	if err != nil {
+		p.notifier.Warn("fetching playlist tracks", err, "playlist", pl.name)
+		return
+	}
+
+	p.cacheMu.Lock()
+	p.cache[pl.name] = cached{count: pl.count, tracks: tracks}
+	p.cacheMu.Unlock()
+
+	p.app.QueueUpdateDraw(func() {
+		if name, ok := p.selected(); ok && name == pl.name {
+			row, _ := p.listPane.GetSelection()
+			p.drawTracks(row)
+		}
+	})
+}
+
+func (p *Playlists) fetchTracks(name string) ([]track, error) {
+	body, err := p.get("/Songs?service=LocalMusic&playlist=" + url.QueryEscape(name))
+	if err != nil {
+		return nil, err
+	}
+
+	var s songs
+
+	err = xml.Unmarshal(body, &s)
+	if err != nil {
+		return nil, err
+	}
+
+	var tracks []track
+	for _, t := range s.Song {
+		tracks = append(tracks, track{title: t.Title, artist: t.Artist, album: t.Album})
+	}
+
+	return tracks, nil
+}
+
+func (p *Playlists) drawList() {
+	row, _ := p.listPane.GetSelection()
+	p.listPane.Clear()
+
+	for i, pl := range p.playlists {
+		p.listPane.SetCell(i, 0, tview.NewTableCell(internal.EscapeStyleTag(pl.name)).
+			SetTextColor(theme.Current.Text).
+			SetExpansion(1).
+			SetTransparency(true))
+
+		p.listPane.SetCell(i, 1, tview.NewTableCell(fmt.Sprintf("%d tracks", pl.count)).
+			SetTextColor(theme.Current.Text).
+			SetAlign(tview.AlignRight).
+			SetTransparency(true))
+	}
+
+	if row >= len(p.playlists) {
+		row = len(p.playlists) - 1
+	}
+
+	if row < 0 {
+		row = 0
+	}
+
+	p.listPane.Select(row, 0)
+	p.drawTracks(row)
+}
+
+func (p *Playlists) drawTracks(row int) {
+	p.tracksPane.Clear().ScrollToBeginning()
+
+	if row < 0 || row >= len(p.playlists) {
+		return
+	}
+
+	tracks, ok := p.cachedTracks(p.playlists[row])
+	if !ok {
+		go p.loadTracks(p.playlists[row])
+		return
+	}
+
+	for i, t := range tracks {
+		p.tracksPane.SetCell(i, 0, tview.NewTableCell(internal.EscapeStyleTag(t.title)).
+			SetTextColor(theme.Current.Text).
+			SetExpansion(2).
+			SetTransparency(true))
+
+		p.tracksPane.SetCell(i, 1, tview.NewTableCell(internal.EscapeStyleTag(t.artist)).
+			SetTextColor(theme.Current.Text).
+			SetExpansion(1).
+			SetTransparency(true))
+
+		p.tracksPane.SetCell(i, 2, tview.NewTableCell(internal.EscapeStyleTag(t.album)).
+			SetTextColor(theme.Current.Text).
+			SetExpansion(1).
+			SetTransparency(true))
+	}
+}
+
+func (p *Playlists) selected() (string, bool) {
+	row, _ := p.listPane.GetSelection()
+	if row < 0 || row >= len(p.playlists) {
+		return "", false
+	}
+
+	return p.playlists[row].name, true
+}
+
+// play replaces the play queue with the playlist at index pl and starts
+// playing it from its track at index track.
+func (p *Playlists) play(pl, track int) {
+	if pl < 0 || pl >= len(p.playlists) {
+		return
+	}
+
+	n := url.QueryEscape(p.playlists[pl].name)
+
+	go func() {
+		p.player.Play("/Load?name=" + n)
+		p.player.Play(fmt.Sprintf("/Play?id=%d", track))
+	}()
+}
+
+// appendToQueue adds all tracks of the selected playlist to the end of the
+// play queue without interrupting the playback.
+func (p *Playlists) appendToQueue() {
+	name, ok := p.selected()
+	if !ok {
+		return
+	}
+
+	go p.player.Play("/Add?service=LocalMusic&where=last&playlist=" + url.QueryEscape(name))
+}
+
+// rename asks for a new name of the selected playlist and renames it.
+func (p *Playlists) rename() {
+	name, ok := p.selected()
+	if !ok {
+		return
+	}
+
+	p.prompter.Prompt("rename playlist to: ", name, func(n string) {
+		if n == name {
+			return
+		}
+
+		go func() {
+			_, err := p.get("/Rename?name=" + url.QueryEscape(name) + "&newname=" + url.QueryEscape(n))
+			if err != nil {
+				p.notifier.Error("renaming playlist", err, "playlist", name)
+				return
+			}
+
+			// either name may be given to another playlist of the same length
+			p.forget(name)
+			p.forget(n)
+			p.FetchData()
+		}()
+	})
+}
+
+// remove asks for a confirmation and deletes the selected playlist.
+func (p *Playlists) remove() {
+	name, ok := p.selected()
+	if !ok {
+		return
+	}
+
+	p.prompter.Prompt("delete playlist "+name+"? (y/n): ", "", func(answer string) {
+		if answer != "y" && answer != "yes" {
+			return
+		}
+
+		go func() {
+			_, err := p.get("/Delete?name=" + url.QueryEscape(name))
+			if err != nil {
+				p.notifier.Error("deleting playlist", err, "playlist", name)
+				return
+			}
+
+			p.forget(name)
+			p.FetchData()
+		}()
+	})
+}
+
+// SaveQueue saves the current play queue as a playlist named name,
+// replacing a playlist with the same name if it exists.
+func (p *Playlists) SaveQueue(name string) {
+	_, err := p.get("/Save?name=" + url.QueryEscape(name))
+	if err != nil {
+		p.notifier.Error("saving play queue", err, "playlist", name)
+		return
+	}
+
+	// a replaced playlist may keep its number of tracks
+	p.forget(name)
+	p.FetchData()
+}
+
+func (p *Playlists) get(endpoint string) ([]byte, error) {
+	resp, err := http.Get(p.API + endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected response: " + resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,211 @@
+package playlist
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/tview"
+)
+
+// fakeApp runs updates right away, one at a time, as if it was the event
+// loop.
+type fakeApp struct{ mu sync.Mutex }
+
+func (f *fakeApp) QueueUpdateDraw(u func()) *tview.Application {
+	f.do(u)
+	return nil
+}
+
+func (f *fakeApp) do(u func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u()
+}
+
+func (f *fakeApp) PrevFocused() tview.Primitive                { return nil }
+func (f *fakeApp) SetFocus(tview.Primitive) *tview.Application { return nil }
+func (f *fakeApp) SetPrevFocused(string)                       {}
+
+// answer is a prompter answering every prompt with itself.
+type answer string
+
+func (a answer) Prompt(_, _ string, done func(string)) { done(string(a)) }
+
+type nopSpinner struct{}
+
+func (nopSpinner) Start() {}
+func (nopSpinner) Stop()  {}
+
+type nopNotifier struct{}
+
+func (nopNotifier) Info(string)                 {}
+func (nopNotifier) Warn(string, error, ...any)  {}
+func (nopNotifier) Error(string, error, ...any) {}
+
+// fakePlayer serves saved playlists and sends every request it gets to
+// reqs.
+type fakePlayer struct {
+	mu        sync.Mutex
+	playlists []string
+	tracks    map[string][]string
+	reqs      chan string
+}
+
+func (f *fakePlayer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	q := r.URL.Query()
+
+	switch r.URL.Path {
+	case "/Playlists":
+		fmt.Fprint(w, "<playlists>")
+		for _, n := range f.playlists {
+			fmt.Fprintf(w, `<name tracks="%d">%s</name>`, len(f.tracks[n]), n)
+		}
+		fmt.Fprint(w, "</playlists>")
+	case "/Songs":
+		fmt.Fprint(w, "<songs>")
+		for _, t := range f.tracks[q.Get("playlist")] {
+			fmt.Fprintf(w, "<song><title>%s</title></song>", t)
+		}
+		fmt.Fprint(w, "</songs>")
+	case "/Rename":
+		from, to := q.Get("name"), q.Get("newname")
+		for i, n := range f.playlists {
+			if n == from {
+				f.playlists[i] = to
+			}
+		}
+
+		f.tracks[to] = f.tracks[from]
+		delete(f.tracks, from)
+	case "/Delete":
+		name := q.Get("name")
+		for i, n := range f.playlists {
+			if n == name {
+				f.playlists = append(f.playlists[:i], f.playlists[i+1:]...)
+				break
+			}
+		}
+
+		delete(f.tracks, name)
+	}
+
+	f.reqs <- r.URL.RequestURI()
+}
+
+// waitFor skips requests until one starting with prefix.
+func (f *fakePlayer) waitFor(t *testing.T, prefix string) {
+	t.Helper()
+
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case r := <-f.reqs:
+			if strings.HasPrefix(r, prefix) {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no request for %s", prefix)
+		}
+	}
+}
+
+func start(t *testing.T, a answer) (*Playlists, *fakePlayer, *fakeApp) {
+	fp := &fakePlayer{
+		playlists: []string{"Late Night", "Mix"},
+		tracks: map[string][]string{
+			"Late Night": {"Lullaby", "Words", "Slide"},
+			"Mix":        {"Sunflower", "Canada"},
+		},
+		reqs: make(chan string, 64),
+	}
+
+	srv := httptest.NewServer(fp)
+	t.Cleanup(srv.Close)
+
+	n := nopNotifier{}
+	pl := player.New(srv.URL, nopSpinner{}, n, make(chan player.Status, 16), player.VolumeConfig{})
+
+	fa := &fakeApp{}
+	p := New(srv.URL, fa, pl, nopSpinner{}, a, n, nil)
+	p.CreateContainer()
+	p.FetchData()
+
+	return p, fp, fa
+}
+
+func TestPlay(t *testing.T) {
+	p, fp, fa := start(t, "")
+
+	fa.do(func() { p.play(0, 2) })
+
+	fp.waitFor(t, "/Load?name=Late+Night")
+	fp.waitFor(t, "/Play?id=2")
+}
+
+func TestTracksCache(t *testing.T) {
+	p, fp, fa := start(t, "Tape")
+
+	// the first playlist is selected and its tracks fetched
+	fp.waitFor(t, "/Songs?service=LocalMusic&playlist=Late+Night")
+
+	rows := func() (n int) {
+		fa.do(func() { n = p.tracksPane.GetRowCount() })
+		return n
+	}
+
+	for deadline := time.Now().Add(2 * time.Second); rows() != 3; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("shown %d tracks, want 3", rows())
+		}
+	}
+
+	mix := playlist{name: "Mix", count: 2}
+
+	fa.do(func() { p.listPane.Select(1, 0) })
+	fp.waitFor(t, "/Songs?service=LocalMusic&playlist=Mix")
+
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, ok := p.cachedTracks(mix); ok {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("tracks of Mix were not kept")
+		}
+	}
+
+	// tracks are forgotten before the playlists are fetched again
+	fa.do(p.rename)
+	fp.waitFor(t, "/Rename?name=Mix&newname=Tape")
+	fp.waitFor(t, "/Playlists")
+
+	if _, ok := p.cachedTracks(mix); ok {
+		t.Error("tracks of the renamed playlist were kept")
+	}
+
+	late := playlist{name: "Late Night", count: 3}
+	if _, ok := p.cachedTracks(late); !ok {
+		t.Fatal("tracks of Late Night were not kept")
+	}
+
+	fa.do(func() {
+		p.listPane.Select(0, 0)
+		p.prompter = answer("y")
+	})
+	fa.do(p.remove)
+	fp.waitFor(t, "/Delete?name=Late+Night")
+	fp.waitFor(t, "/Playlists")
+
+	if _, ok := p.cachedTracks(late); ok {
+		t.Error("tracks of the deleted playlist were kept")
+	}
+}
@@ -0,0 +1,68 @@
+package radio
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/mkozjak/blutui/internal/config"
+)
+
+// favouritesFile is the name of a file in the config directory
+// that holds user's favourite stations.
+const favouritesFile = "stations.json"
+
+// A Station is a radio station that can be saved as a favourite. Key and
+// Query tell the browse level it was found on, which is fetched again to
+// show what the station currently plays.
+type Station struct {
+	Name    string `json:"name"`
+	Service string `json:"service,omitempty"`
+	PlayURL string `json:"playUrl"`
+	Image   string `json:"image,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Query   string `json:"query,omitempty"`
+}
+
+// loadFavourites reads favourite stations from the config directory.
+// A missing file means there are no favourites yet.
+func loadFavourites() ([]Station, error) {
+	path, err := config.Path(favouritesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var st []Station
+
+	err = json.Unmarshal(data, &st)
+	if err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// saveFavourites writes favourite stations to the config directory.
+func saveFavourites(st []Station) error {
+	path, err := config.Path(favouritesFile)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,36 @@
+package radio
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mkozjak/blutui/internal/keymap"
+)
+
+func (r *Radio) KeyboardHandler(event *tcell.EventKey) *tcell.EventKey {
+	switch r.keys.Action(event, keymap.Navigation, keymap.Radio) {
+	case keymap.RadioBack:
+		r.back()
+		return nil
+	case keymap.Select:
+		return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+	case keymap.PageUp:
+		return tcell.NewEventKey(tcell.KeyPgUp, 0, tcell.ModNone)
+	case keymap.PageDown:
+		return tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone)
+	case keymap.Down:
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case keymap.Up:
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	case keymap.Top:
+		return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+	case keymap.Bottom:
+		return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+	case keymap.SearchStations:
+		r.search()
+		return nil
+	case keymap.ToggleFavourite:
+		r.toggleFavourite()
+		return nil
+	}
+
+	return event
+}
@@ -0,0 +1,618 @@
+package radio
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/app"
+	"github.com/mkozjak/blutui/internal/keymap"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+	"github.com/mkozjak/blutui/internal/theme"
+	"github.com/mkozjak/blutui/spinner"
+	"github.com/mkozjak/tview"
+)
+
+// Used for parsing data from /Browse. Radio services often group their
+// items into categories, such as "Local Radio" or "Trending".
+type browse struct {
+	SearchKey  string `xml:"searchKey,attr"`
+	Items      []item `xml:"item"`
+	Categories []struct {
+		Text  string `xml:"text,attr"`
+		Items []item `xml:"item"`
+	} `xml:"category"`
+}
+
+type item struct {
+	Text        string `xml:"text,attr"`  // station or category name
+	Text2       string `xml:"text2,attr"` // station's now playing info
+	BrowseKey   string `xml:"browseKey,attr"`
+	Type        string `xml:"type,attr"`
+	PlayURL     string `xml:"playURL,attr"`
+	AutoplayURL string `xml:"autoplayURL,attr"`
+	Image       string `xml:"image,attr"`
+}
+
+// A level is a single level of a radio browse tree shown on the page,
+// such as the list of services, a genre or search results.
+type level struct {
+	title     string
+	key       string
+	service   string
+	searchKey string
+	query     string
+	items     []item
+	selected  int
+}
+
+// Keys of the root level entries that are not fetched from the player.
+const (
+	favouritesKey = ":favourites"
+	rootKey       = ":root"
+)
+
+// fallbackServices are shown when the player's root browse menu can't be fetched.
+var fallbackServices = []item{
+	{Text: "TuneIn", BrowseKey: "TuneIn:"},
+	{Text: "Radio Paradise", BrowseKey: "RadioParadise:"},
+}
+
+// refreshInterval defines how often station metadata, such as the currently
+// played song, is refreshed while the radio page is shown.
+const refreshInterval = 30 * time.Second
+
+// Prompter asks the user for a single line of input on the bottom bar.
+type Prompter interface {
+	Prompt(label, text string, done func(input string))
+}
+
+type appManager interface {
+	app.Focuser
+	app.PageViewer
+	app.Updater
+}
+
+// Radio represents a page for browsing internet radio services offered by the
+// player, such as TuneIn or Radio Paradise, searching and playing their stations
+// and keeping user's favourite stations.
+type Radio struct {
+	container *tview.Flex
+	app       appManager
+	player    player.Controller
+	spinner   spinner.StartStopper
+	prompter  Prompter
+	notifier  notify.Notifier
+	keys      *keymap.Keymap
+	API       string
+
+	stationPane *tview.Table
+	mu          sync.Mutex
+	levels      []*level
+	favourites  []Station
+	// What favourite stations currently play, by their play URL
+	nowPlaying map[string]string
+
+	// Whether radio services couldn't be fetched, so fallbacks are shown
+	failed atomic.Bool
+}
+
+func New(api string, a appManager, p player.Controller, sp spinner.StartStopper, pr Prompter, n notify.Notifier,
+	k *keymap.Keymap) *Radio {
+	return &Radio{
+		app:      a,
+		player:   p,
+		spinner:  sp,
+		prompter: pr,
+		notifier: n,
+		keys:     k,
+		API:      api,
+	}
+}
+
+func (r *Radio) CreateContainer() *tview.Flex {
+	r.stationPane = tview.NewTable().
+		SetSelectable(true, false).
+		SetSelectedStyle(theme.Current.SelectedStyle(true))
+
+	r.stationPane.SetBorder(true).
+		SetBorderColor(theme.Current.Border).
+		SetBackgroundColor(tcell.ColorDefault).
+		SetTitleAlign(tview.AlignLeft).
+		SetCustomBorders(internal.CustomBorders).
+		SetBlurFunc(func() {
+			r.app.SetPrevFocused("radio")
+		})
+
+	r.stationPane.SetSelectedFunc(func(row, _ int) {
+		r.open(row)
+	})
+
+	r.container = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(r.stationPane, 0, 1, true)
+
+	r.container.SetInputCapture(r.KeyboardHandler)
+
+	return r.container
+}
+
+// FetchData loads favourite stations and radio services offered by the player,
+// draws the root level and starts refreshing station metadata in the background.
+func (r *Radio) FetchData() {
+	r.load()
+	go r.refreshLoop()
+}
+
+// Retry fetches radio services again if they couldn't be fetched before,
+// e.g. because the player was offline, and redraws the root level.
+func (r *Radio) Retry() {
+	if r.failed.Load() {
+		r.load()
+	}
+}
+
+// load loads favourite stations and radio services and draws the root level.
+func (r *Radio) load() {
+	favs, err := loadFavourites()
+	if err != nil {
+		r.notifier.Error("loading favourite stations", err)
+	}
+
+	services := r.services()
+
+	r.mu.Lock()
+	r.favourites = favs
+	r.levels = []*level{{title: "Radio", key: rootKey, items: services}}
+	r.mu.Unlock()
+
+	r.app.QueueUpdateDraw(r.draw)
+}
+
+// services returns the root browse menu items of the player that are radio services.
+func (r *Radio) services() []item {
+	b, err := r.browse("")
+	r.failed.Store(err != nil)
+
+	if err != nil {
+		r.notifier.Warn("fetching radio services", err)
+		return fallbackServices
+	}
+
+	var s []item
+	for _, it := range b.Items {
+		n := strings.ToLower(it.Text)
+		if it.BrowseKey != "" && (strings.Contains(n, "radio") || strings.Contains(n, "tunein")) {
+			s = append(s, it)
+		}
+	}
+
+	if len(s) == 0 {
+		return fallbackServices
+	}
+
+	return s
+}
+
+// refreshLoop periodically refetches the current level while the radio page
+// is shown, so that stations show what they are currently playing.
+func (r *Radio) refreshLoop() {
+	t := time.NewTicker(refreshInterval)
+	defer t.Stop()
+
+	for range t.C {
+		if r.app.CurrentPage() != "radio" {
+			continue
+		}
+
+		r.mu.Lock()
+		l := r.levels[len(r.levels)-1]
+		key, q := l.key, l.query
+		r.mu.Unlock()
+
+		if key == favouritesKey {
+			r.refreshFavourites()
+			continue
+		}
+
+		if strings.HasPrefix(key, ":") {
+			continue
+		}
+
+		b, err := r.browseQuery(key, q)
+		if err != nil {
+			// refreshing goes on in the background, so it's only logged
+			slog.Warn("refreshing radio stations", "err", err, "key", key)
+			continue
+		}
+
+		r.mu.Lock()
+		// Only update if the user didn't move elsewhere in the meantime
+		if r.levels[len(r.levels)-1] == l {
+			l.items = flatten(b)
+		}
+		r.mu.Unlock()
+
+		r.app.QueueUpdateDraw(r.draw)
+	}
+}
+
+// refreshFavourites fetches levels favourite stations were found on again,
+// so that they show what they currently play like other stations do.
+func (r *Radio) refreshFavourites() {
+	r.mu.Lock()
+	favs := slices.Clone(r.favourites)
+	r.mu.Unlock()
+
+	type source struct{ key, query string }
+
+	fetched := map[source]bool{}
+	playing := map[string]string{}
+
+	for _, f := range favs {
+		s := source{f.Key, f.Query}
+		if f.Key == "" || fetched[s] {
+			continue
+		}
+
+		fetched[s] = true
+
+		b, err := r.browseQuery(f.Key, f.Query)
+		if err != nil {
+			slog.Warn("refreshing favourite stations", "err", err, "key", f.Key)
+			continue
+		}
+
+		for _, it := range flatten(b) {
+			if it.PlayURL != "" && it.Text2 != "" {
+				playing[it.PlayURL] = it.Text2
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.nowPlaying = playing
+	r.mu.Unlock()
+
+	r.app.QueueUpdateDraw(r.draw)
+}
+
+func (r *Radio) browse(key string) (*browse, error) {
+	return r.browseQuery(key, "")
+}
+
+// browseQuery fetches a browse level given its key and a search query, if any.
+func (r *Radio) browseQuery(key, q string) (*browse, error) {
+	endpoint := "/Browse"
+	if key != "" {
+		endpoint += "?key=" + url.QueryEscape(key)
+	}
+
+	if q != "" {
+		endpoint += "&q=" + url.QueryEscape(q)
+	}
+
+	return r.get(endpoint)
+}
+
+func (r *Radio) get(endpoint string) (*browse, error) {
+	go r.spinner.Start()
+	defer r.spinner.Stop()
+
+	resp, err := http.Get(r.API + endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected response: " + resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var b browse
+
+	err = xml.Unmarshal(body, &b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// flatten returns items of b along with the items of its categories.
+func flatten(b *browse) []item {
+	items := b.Items
+
+	for _, c := range b.Categories {
+		items = append(items, c.Items...)
+	}
+
+	return items
+}
+
+// current returns the currently shown level along with its items.
+// Root level items are prepended with the favourite stations entry, and
+// favourite stations show what they play if known, or their service.
+func (r *Radio) current() (*level, []item) {
+	l := r.levels[len(r.levels)-1]
+
+	if l.key == rootKey {
+		return l, append([]item{{Text: "★ Favourites", BrowseKey: favouritesKey}}, l.items...)
+	}
+
+	if l.key == favouritesKey {
+		var items []item
+		for _, f := range r.favourites {
+			text2 := f.Service
+			if np := r.nowPlaying[f.PlayURL]; np != "" {
+				text2 = np
+			}
+
+			items = append(items, item{Text: f.Name, PlayURL: f.PlayURL, Image: f.Image, Text2: text2})
+		}
+
+		return l, items
+	}
+
+	return l, l.items
+}
+
+func (r *Radio) draw() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.levels) == 0 {
+		return
+	}
+
+	l, items := r.current()
+
+	var path []string
+	for _, lv := range r.levels {
+		path = append(path, lv.title)
+	}
+
+	r.stationPane.SetTitle(" [::b]" + internal.EscapeStyleTag(strings.Join(path, " › ")) + " ")
+	r.stationPane.Clear()
+
+	for i, it := range items {
+		name := it.Text
+		if it.PlayURL != "" && r.isFavourite(it.PlayURL) {
+			name = "★ " + name
+		} else if it.BrowseKey != "" {
+			name = name + " ›"
+		}
+
+		r.stationPane.SetCell(i, 0, tview.NewTableCell(internal.EscapeStyleTag(name)).
+			SetTextColor(theme.Current.Text).
+			SetExpansion(1).
+			SetTransparency(true))
+
+		r.stationPane.SetCell(i, 1, tview.NewTableCell(internal.EscapeStyleTag(it.Text2)).
+			SetTextColor(theme.Current.Dim).
+			SetExpansion(2).
+			SetTransparency(true))
+	}
+
+	if l.selected >= len(items) {
+		l.selected = len(items) - 1
+	}
+
+	r.stationPane.Select(max(l.selected, 0), 0)
+}
+
+// open plays a station or enters a browse level given its row.
+func (r *Radio) open(row int) {
+	r.mu.Lock()
+	if len(r.levels) == 0 {
+		r.mu.Unlock()
+		return
+	}
+
+	l, items := r.current()
+	if row < 0 || row >= len(items) {
+		r.mu.Unlock()
+		return
+	}
+
+	l.selected = row
+	it := items[row]
+	service := l.service
+	r.mu.Unlock()
+
+	switch {
+	case it.PlayURL != "":
+		go r.player.Play(it.PlayURL)
+	case it.AutoplayURL != "":
+		go r.player.Play(it.AutoplayURL)
+	case it.BrowseKey == favouritesKey:
+		r.push(&level{title: "Favourites", key: favouritesKey})
+		go r.refreshFavourites()
+	case it.BrowseKey != "":
+		if service == "" {
+			service = it.Text
+		}
+
+		go r.enter(&level{title: it.Text, key: it.BrowseKey, service: service})
+	}
+}
+
+// enter fetches a browse level and shows it.
+func (r *Radio) enter(l *level) {
+	b, err := r.browse(l.key)
+	if err != nil {
+		r.notifier.Error("opening", err, "level", l.title)
+		return
+	}
+
+	l.items = flatten(b)
+	l.searchKey = b.SearchKey
+
+	r.app.QueueUpdateDraw(func() {
+		r.push(l)
+	})
+}
+
+func (r *Radio) push(l *level) {
+	r.mu.Lock()
+	// inherit search key of the service so that search works in subcategories
+	if l.searchKey == "" {
+		l.searchKey = r.levels[len(r.levels)-1].searchKey
+	}
+
+	r.levels = append(r.levels, l)
+	r.mu.Unlock()
+
+	r.draw()
+}
+
+// back returns to the previous browse level.
+func (r *Radio) back() {
+	r.mu.Lock()
+	if len(r.levels) < 2 {
+		r.mu.Unlock()
+		return
+	}
+
+	r.levels = r.levels[:len(r.levels)-1]
+	r.mu.Unlock()
+
+	r.draw()
+}
+
+// search asks for a query and searches stations of the current service.
+// On the root level, the first service that supports search is used.
+func (r *Radio) search() {
+	r.mu.Lock()
+	if len(r.levels) == 0 {
+		r.mu.Unlock()
+		return
+	}
+
+	l := r.levels[len(r.levels)-1]
+	key, service := l.searchKey, l.service
+	r.mu.Unlock()
+
+	label := "search stations: "
+	if service != "" {
+		label = "search " + service + ": "
+	}
+
+	r.prompter.Prompt(label, "", func(q string) {
+		go func() {
+			k, s := key, service
+			if k == "" {
+				k, s = r.defaultSearchKey()
+			}
+
+			if k == "" {
+				r.notifier.Info("search is not supported here")
+				return
+			}
+
+			b, err := r.browseQuery(k, q)
+			if err != nil {
+				r.notifier.Error("searching radio stations", err, "query", q)
+				return
+			}
+
+			nl := &level{title: "search: " + q, key: k, service: s, searchKey: k, query: q, items: flatten(b)}
+
+			r.app.QueueUpdateDraw(func() {
+				r.push(nl)
+			})
+		}()
+	})
+}
+
+// defaultSearchKey returns the search key of the first radio service supporting search.
+func (r *Radio) defaultSearchKey() (string, string) {
+	r.mu.Lock()
+	services := slices.Clone(r.levels[0].items)
+	r.mu.Unlock()
+
+	for _, s := range services {
+		b, err := r.browse(s.BrowseKey)
+		if err != nil || b.SearchKey == "" {
+			continue
+		}
+
+		return b.SearchKey, s.Text
+	}
+
+	return "", ""
+}
+
+func (r *Radio) isFavourite(playURL string) bool {
+	return slices.ContainsFunc(r.favourites, func(s Station) bool {
+		return s.PlayURL == playURL
+	})
+}
+
+// toggleFavourite adds the selected station to favourites or removes it if
+// it is already there, and saves favourites to the config directory.
+func (r *Radio) toggleFavourite() {
+	row, _ := r.stationPane.GetSelection()
+
+	r.mu.Lock()
+	if len(r.levels) == 0 {
+		r.mu.Unlock()
+		return
+	}
+
+	l, items := r.current()
+	if row < 0 || row >= len(items) || items[row].PlayURL == "" {
+		r.mu.Unlock()
+		return
+	}
+
+	l.selected = row
+	it := items[row]
+
+	var msg string
+	if r.isFavourite(it.PlayURL) {
+		r.favourites = slices.DeleteFunc(r.favourites, func(s Station) bool {
+			return s.PlayURL == it.PlayURL
+		})
+		msg = "removed from favourites: " + it.Text
+	} else {
+		r.favourites = append(r.favourites, Station{
+			Name:    it.Text,
+			Service: l.service,
+			PlayURL: it.PlayURL,
+			Image:   it.Image,
+			Key:     l.key,
+			Query:   l.query,
+		})
+		msg = "added to favourites: " + it.Text
+	}
+
+	favs := slices.Clone(r.favourites)
+	r.mu.Unlock()
+
+	r.draw()
+
+	go func() {
+		if err := saveFavourites(favs); err != nil {
+			r.notifier.Error("saving favourite stations", err)
+			return
+		}
+
+		r.notifier.Info(msg)
+	}()
+}
@@ -0,0 +1,131 @@
+// Package reindex has the player rescan its library and follows the rescan
+// until it's done, so that music added to the player's shares shows up.
+package reindex
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	internal "github.com/mkozjak/blutui/internal"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+// Indexer is implemented by players that can rescan their library,
+// such as [player.Client].
+type Indexer interface {
+	Reindex() error
+}
+
+// Subscriber is implemented by players publishing their status, which tells
+// whether they are indexing, such as [player.Player].
+type Subscriber interface {
+	Subscribe() <-chan player.Status
+	Unsubscribe(ch <-chan player.Status)
+}
+
+const (
+	// tickInterval is the time between updates of the shown progress.
+	tickInterval = time.Second
+
+	// startTimeout is the time after which a rescan that never showed up as
+	// indexing is taken as done, as quick ones may finish between updates.
+	startTimeout = 10 * time.Second
+)
+
+// A Monitor starts rescans and shows their progress until they are done.
+type Monitor struct {
+	indexer  Indexer
+	statuses Subscriber
+	notifier notify.Notifier
+	progress func(text string)
+	done     func()
+
+	// Whether a rescan is being followed
+	running atomic.Bool
+
+	interval     time.Duration
+	startTimeout time.Duration
+}
+
+// New returns a new [Monitor] given the player to rescan, the player whose
+// status tells when the rescan is done, a notifier failures are reported to,
+// a function showing progress, called with an empty text once the rescan
+// ends, and a function called once it's done, e.g. updating the library.
+func New(ix Indexer, st Subscriber, n notify.Notifier, progress func(text string), done func()) *Monitor {
+	return &Monitor{
+		indexer:      ix,
+		statuses:     st,
+		notifier:     n,
+		progress:     progress,
+		done:         done,
+		interval:     tickInterval,
+		startTimeout: startTimeout,
+	}
+}
+
+// Start has the player rescan its library and waits for its status to tell
+// the rescan finished, showing the time it has taken meanwhile, as the player
+// doesn't report how far it got. Only one rescan is followed at a time.
+func (m *Monitor) Start() {
+	if !m.running.CompareAndSwap(false, true) {
+		m.notifier.Info("library rescan is already running")
+		return
+	}
+	defer m.running.Store(false)
+
+	ch := m.statuses.Subscribe()
+	defer m.statuses.Unsubscribe(ch)
+
+	if err := m.indexer.Reindex(); err != nil {
+		m.notifier.Error("starting library rescan", err)
+		return
+	}
+
+	m.notifier.Info("library rescan started")
+
+	if err := m.wait(ch); err != nil {
+		m.progress("")
+		m.notifier.Warn("following library rescan", err)
+		return
+	}
+
+	m.progress("")
+	m.notifier.Info("library rescan finished")
+	m.done()
+}
+
+// wait shows progress until statuses received on ch tell that the rescan
+// is done.
+func (m *Monitor) wait(ch <-chan player.Status) error {
+	t := time.NewTicker(m.interval)
+	defer t.Stop()
+
+	start := time.Now()
+	seen := false
+
+	for {
+		m.progress("indexing library " + internal.FormatDuration(int(time.Since(start).Seconds())))
+
+		select {
+		case s, ok := <-ch:
+			switch {
+			case !ok:
+				return errors.New("player status closed")
+			case s.State == "offline":
+				return errors.New("player went offline")
+			case s.State == "ctrlerr":
+				continue
+			case s.Indexing > 0:
+				seen = true
+			case seen:
+				return nil
+			}
+		case <-t.C:
+			if !seen && time.Since(start) >= m.startTimeout {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package reindex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+// fakePlayer publishes statuses once a rescan starts.
+type fakePlayer struct {
+	err      error
+	started  int
+	statuses []player.Status
+	ch       chan player.Status
+}
+
+func (f *fakePlayer) Reindex() error {
+	f.started++
+
+	for _, s := range f.statuses {
+		f.ch <- s
+	}
+
+	return f.err
+}
+
+func (f *fakePlayer) Subscribe() <-chan player.Status {
+	f.ch = make(chan player.Status, len(f.statuses))
+	return f.ch
+}
+
+func (f *fakePlayer) Unsubscribe(ch <-chan player.Status) {
+	f.ch = nil
+}
+
+func TestStart(t *testing.T) {
+	indexing := player.Status{State: "stop", Indexing: 1}
+	idle := player.Status{State: "stop"}
+
+	tests := []struct {
+		name     string
+		player   *fakePlayer
+		finished bool
+	}{
+		{"indexing", &fakePlayer{statuses: []player.Status{idle, indexing, indexing, idle}}, true},
+		{"quick", &fakePlayer{}, true},
+		{"offline", &fakePlayer{statuses: []player.Status{indexing, {State: "offline"}}}, false},
+		{"failed", &fakePlayer{err: errors.New("refused")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var progress []string
+			finished := false
+
+			m := New(tt.player, tt.player, notify.Log, func(text string) {
+				progress = append(progress, text)
+			}, func() {
+				finished = true
+			})
+			m.interval, m.startTimeout = time.Millisecond, 3*time.Millisecond
+
+			m.Start()
+
+			if tt.player.started != 1 || finished != tt.finished {
+				t.Errorf("started %d times, finished %v", tt.player.started, finished)
+			}
+
+			if tt.player.ch != nil {
+				t.Error("statuses are still subscribed to")
+			}
+
+			if tt.player.err != nil {
+				return
+			}
+
+			if len(progress) < 2 || !strings.HasPrefix(progress[0], "indexing library") ||
+				progress[len(progress)-1] != "" {
+				t.Errorf("progress %q", progress)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package scrobble
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/mkozjak/blutui/internal/config"
+)
+
+// ConfigFile is the name of a file in the config directory that enables
+// scrobbling, e.g.
+//
+//	{
+//	  "listenbrainz": {"token": "..."},
+//	  "lastfm": {"apiKey": "...", "secret": "...", "sessionKey": "..."}
+//	}
+const ConfigFile = "scrobble.json"
+
+// Config holds settings of scrobbling backends. Backends that are not set
+// are disabled.
+type Config struct {
+	ListenBrainz *ListenBrainzConfig `json:"listenbrainz,omitempty"`
+	LastFM       *LastFMConfig       `json:"lastfm,omitempty"`
+}
+
+// ListenBrainzConfig holds settings of a ListenBrainz compatible server.
+type ListenBrainzConfig struct {
+	// URL is server's base URL, defaulting to [ListenBrainzURL].
+	URL   string `json:"url,omitempty"`
+	Token string `json:"token"`
+}
+
+// LastFMConfig holds settings of a Last.fm compatible server.
+// The session key is obtained by authenticating with the api key.
+type LastFMConfig struct {
+	// URL is server's API root, defaulting to [LastFMURL].
+	URL        string `json:"url,omitempty"`
+	APIKey     string `json:"apiKey"`
+	Secret     string `json:"secret"`
+	SessionKey string `json:"sessionKey"`
+}
+
+// LoadConfig reads scrobbling settings from the config directory.
+// A missing file means scrobbling is disabled.
+func LoadConfig() (Config, error) {
+	var c Config
+
+	path, err := config.Path(ConfigFile)
+	if err != nil {
+		return c, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c, nil
+		}
+
+		return c, err
+	}
+
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// Backends returns backends enabled in c.
+func (c Config) Backends() []Backend {
+	var b []Backend
+
+	if c.ListenBrainz != nil && c.ListenBrainz.Token != "" {
+		b = append(b, NewListenBrainz(c.ListenBrainz.URL, c.ListenBrainz.Token))
+	}
+
+	if c.LastFM != nil && c.LastFM.SessionKey != "" {
+		b = append(b, NewLastFM(c.LastFM.URL, c.LastFM.APIKey, c.LastFM.Secret, c.LastFM.SessionKey))
+	}
+
+	return b
+}
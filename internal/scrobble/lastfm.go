@@ -0,0 +1,157 @@
+package scrobble
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mkozjak/blutui/internal/history"
+)
+
+// LastFMURL is the API root of Last.fm.
+const LastFMURL = "https://ws.audioscrobbler.com/2.0/"
+
+// lastFMBatch is the largest number of scrobbles Last.fm accepts at once.
+const lastFMBatch = 50
+
+// Last.fm error codes worth retrying: service offline,
+// temporarily unavailable and rate limit exceeded.
+var lastFMTemporary = map[int]bool{11: true, 16: true, 29: true}
+
+// Used for parsing error responses
+type lastFMError struct {
+	Code    int    `json:"error"`
+	Message string `json:"message"`
+}
+
+// LastFM submits scrobbles using the Last.fm submission protocol.
+type LastFM struct {
+	url        string
+	apiKey     string
+	secret     string
+	sessionKey string
+}
+
+func NewLastFM(url, apiKey, secret, sessionKey string) *LastFM {
+	if url == "" {
+		url = LastFMURL
+	}
+
+	return &LastFM{url: url, apiKey: apiKey, secret: secret, sessionKey: sessionKey}
+}
+
+func (lf *LastFM) Name() string {
+	return "lastfm"
+}
+
+func (lf *LastFM) BatchSize() int {
+	return lastFMBatch
+}
+
+func (lf *LastFM) NowPlaying(p history.Play) error {
+	v := url.Values{}
+	v.Set("artist", p.Artist)
+	v.Set("track", p.Track)
+
+	if p.Album != "" {
+		v.Set("album", p.Album)
+	}
+
+	if p.Length > 0 {
+		v.Set("duration", strconv.Itoa(p.Length))
+	}
+
+	return lf.call("track.updateNowPlaying", v)
+}
+
+func (lf *LastFM) Submit(plays []history.Play) error {
+	v := url.Values{}
+
+	for i, p := range plays {
+		v.Set(fmt.Sprintf("artist[%d]", i), p.Artist)
+		v.Set(fmt.Sprintf("track[%d]", i), p.Track)
+		v.Set(fmt.Sprintf("timestamp[%d]", i), strconv.FormatInt(p.Time.Unix(), 10))
+
+		if p.Album != "" {
+			v.Set(fmt.Sprintf("album[%d]", i), p.Album)
+		}
+
+		if p.Length > 0 {
+			v.Set(fmt.Sprintf("duration[%d]", i), strconv.Itoa(p.Length))
+		}
+	}
+
+	return lf.call("track.scrobble", v)
+}
+
+// call calls an API method with params v, signing the request.
+func (lf *LastFM) call(method string, v url.Values) error {
+	v.Set("method", method)
+	v.Set("api_key", lf.apiKey)
+	v.Set("sk", lf.sessionKey)
+	v.Set("api_sig", lf.sign(v))
+	v.Set("format", "json")
+
+	resp, err := http.PostForm(lf.url, v)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var e lastFMError
+	if json.Unmarshal(body, &e) == nil && e.Code != 0 {
+		err = fmt.Errorf("lastfm: %s: error %d: %s", method, e.Code, e.Message)
+		if lastFMTemporary[e.Code] {
+			return err
+		}
+
+		return permanent(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("lastfm: %s: %s", method, resp.Status)
+
+		// rate limiting and server errors are worth retrying
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return err
+		}
+
+		return permanent(err)
+	}
+
+	return nil
+}
+
+// sign returns the signature of params v: an md5 hash of their names and
+// values, ordered by name, followed by the shared secret.
+func (lf *LastFM) sign(v url.Values) string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString(v.Get(k))
+	}
+
+	b.WriteString(lf.secret)
+
+	sum := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package scrobble
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mkozjak/blutui/internal/history"
+)
+
+// ListenBrainzURL is the base URL of the public ListenBrainz server.
+const ListenBrainzURL = "https://api.listenbrainz.org"
+
+// listenBrainzBatch is the largest number of listens submitted at once.
+const listenBrainzBatch = 100
+
+// Used for submitting listens to /1/submit-listens
+type lbSubmission struct {
+	ListenType string     `json:"listen_type"`
+	Payload    []lbListen `json:"payload"`
+}
+
+type lbListen struct {
+	ListenedAt int64 `json:"listened_at,omitempty"`
+	Track      struct {
+		Artist  string `json:"artist_name"`
+		Track   string `json:"track_name"`
+		Release string `json:"release_name,omitempty"`
+		Info    struct {
+			Duration    int    `json:"duration,omitempty"`
+			MediaPlayer string `json:"media_player"`
+		} `json:"additional_info"`
+	} `json:"track_metadata"`
+}
+
+// ListenBrainz submits listens to a ListenBrainz compatible server.
+type ListenBrainz struct {
+	url   string
+	token string
+}
+
+func NewListenBrainz(url, token string) *ListenBrainz {
+	if url == "" {
+		url = ListenBrainzURL
+	}
+
+	return &ListenBrainz{url: strings.TrimSuffix(url, "/"), token: token}
+}
+
+func (lb *ListenBrainz) Name() string {
+	return "listenbrainz"
+}
+
+func (lb *ListenBrainz) BatchSize() int {
+	return listenBrainzBatch
+}
+
+func (lb *ListenBrainz) NowPlaying(p history.Play) error {
+	return lb.submit("playing_now", []history.Play{p})
+}
+
+func (lb *ListenBrainz) Submit(plays []history.Play) error {
+	t := "import"
+	if len(plays) == 1 {
+		t = "single"
+	}
+
+	return lb.submit(t, plays)
+}
+
+func (lb *ListenBrainz) submit(listenType string, plays []history.Play) error {
+	s := lbSubmission{ListenType: listenType}
+
+	for _, p := range plays {
+		var l lbListen
+		if listenType != "playing_now" {
+			l.ListenedAt = p.Time.Unix()
+		}
+
+		l.Track.Artist = p.Artist
+		l.Track.Track = p.Track
+		l.Track.Release = p.Album
+		l.Track.Info.Duration = p.Length
+		l.Track.Info.MediaPlayer = "blutui"
+
+		s.Payload = append(s.Payload, l)
+	}
+
+	body, err := json.Marshal(s)
+	if err != nil {
+		return permanent(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, lb.url+"/1/submit-listens", bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+
+	req.Header.Set("Authorization", "Token "+lb.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(resp.Body)
+	err = fmt.Errorf("listenbrainz: %s: %s", resp.Status, bytes.TrimSpace(msg))
+
+	// rate limiting and server errors are worth retrying
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+
+	return permanent(err)
+}
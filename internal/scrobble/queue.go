@@ -0,0 +1,112 @@
+package scrobble
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/mkozjak/blutui/internal/history"
+)
+
+// queue keeps plays that failed to be submitted in a JSON lines file,
+// so that they survive restarts until they are retried.
+type queue struct {
+	path string
+	mu   sync.Mutex
+}
+
+// push appends plays to the end of the queue.
+func (q *queue) push(plays []history.Play) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	f, err := os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, p := range plays {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// load returns all queued plays, oldest first.
+func (q *queue) load() ([]history.Play, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data, err := os.ReadFile(q.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var plays []history.Play
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var p history.Play
+		if err := json.Unmarshal(sc.Bytes(), &p); err != nil {
+			continue
+		}
+
+		plays = append(plays, p)
+	}
+
+	return plays, sc.Err()
+}
+
+// drop removes the first n plays returned by [queue.load] from the queue,
+// keeping the ones pushed in the meantime.
+func (q *queue) drop(n int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	data, err := os.ReadFile(q.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	// skip n valid lines, along with malformed ones among them
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	i := 0
+	for ; i < len(lines) && n > 0; i++ {
+		var p history.Play
+		if json.Unmarshal(lines[i], &p) == nil {
+			n--
+		}
+	}
+
+	rest := bytes.Join(lines[i:], nil)
+	if len(bytes.TrimSpace(rest)) == 0 {
+		return os.Remove(q.path)
+	}
+
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, rest, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, q.path)
+}
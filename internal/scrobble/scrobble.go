@@ -0,0 +1,186 @@
+// Package scrobble submits tracks played on the player to ListenBrainz or
+// Last.fm compatible services.
+package scrobble
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/mkozjak/blutui/internal/history"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+// retryInterval defines how often failed submissions are retried.
+const retryInterval = 5 * time.Minute
+
+// Backend submits plays to a scrobbling service.
+type Backend interface {
+	// Name identifies the backend, e.g. in the name of its offline queue.
+	Name() string
+	// BatchSize is the largest number of plays submitted at once.
+	BatchSize() int
+	NowPlaying(p history.Play) error
+	Submit(plays []history.Play) error
+}
+
+// permanentError wraps errors of submissions that would fail again if retried,
+// such as ones rejected for an invalid token.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+func permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var pe permanentError
+	return errors.As(err, &pe)
+}
+
+type target struct {
+	backend Backend
+	queue   *queue
+}
+
+// Scrobbler announces tracks that start playing and submits plays to its
+// backends. Plays that fail to be submitted are queued on disk and retried
+// periodically, until they are accepted or rejected for good.
+type Scrobbler struct {
+	targets  []target
+	notifier notify.Notifier
+	key      string
+}
+
+// New returns a [Scrobbler] submitting to backends b, keeping offline queues
+// in directory dir and reporting failures to n.
+func New(b []Backend, dir string, n notify.Notifier) *Scrobbler {
+	s := &Scrobbler{notifier: n}
+
+	for _, be := range b {
+		s.targets = append(s.targets, target{
+			backend: be,
+			queue:   &queue{path: filepath.Join(dir, "scrobble-"+be.Name()+".jsonl")},
+		})
+	}
+
+	return s
+}
+
+// Listen consumes player status updates given its read-only channel,
+// announcing every track that starts playing. It also retries queued
+// submissions until the channel is closed.
+func (s *Scrobbler) Listen(ch <-chan player.Status) {
+	t := time.NewTicker(retryInterval)
+	defer t.Stop()
+
+	s.Retry()
+
+	for {
+		select {
+		case st, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			s.update(st)
+		case <-t.C:
+			s.Retry()
+		}
+	}
+}
+
+func (s *Scrobbler) update(st player.Status) {
+	if st.State != "play" && st.State != "stream" {
+		return
+	}
+
+	key, p := history.FromStatus(st)
+	if key == s.key || p.Track == "" || p.Artist == "" {
+		return
+	}
+
+	s.key = key
+
+	for _, t := range s.targets {
+		if err := t.backend.NowPlaying(p); err != nil {
+			s.notifier.Warn("announcing now playing to", err, "service", t.backend.Name())
+		}
+	}
+}
+
+// Scrobble submits p to all backends, queueing it for the ones that fail.
+func (s *Scrobbler) Scrobble(p history.Play) {
+	if p.Track == "" || p.Artist == "" {
+		return
+	}
+
+	for _, t := range s.targets {
+		// keep plays in order behind the ones waiting to be retried
+		if q, err := t.queue.load(); err == nil && len(q) > 0 {
+			s.enqueue(t, p)
+			continue
+		}
+
+		err := t.backend.Submit([]history.Play{p})
+		if err == nil {
+			continue
+		}
+
+		if isPermanent(err) {
+			s.notifier.Error("scrobbling to", err, "service", t.backend.Name())
+			continue
+		}
+
+		s.enqueue(t, p)
+	}
+}
+
+func (s *Scrobbler) enqueue(t target, p history.Play) {
+	if err := t.queue.push([]history.Play{p}); err != nil {
+		s.notifier.Error("queueing scrobble for", err, "service", t.backend.Name())
+	}
+}
+
+// Retry submits queued plays of all backends in batches, stopping at the first
+// batch that fails temporarily. Batches rejected for good are dropped.
+func (s *Scrobbler) Retry() {
+	for _, t := range s.targets {
+		plays, err := t.queue.load()
+		if err != nil {
+			s.notifier.Warn("loading scrobble queue of", err, "service", t.backend.Name())
+			continue
+		}
+
+		for len(plays) > 0 {
+			n := min(len(plays), t.backend.BatchSize())
+
+			err := t.backend.Submit(plays[:n])
+			if err != nil && !isPermanent(err) {
+				break
+			}
+
+			if err != nil {
+				s.notifier.Error(fmt.Sprintf("dropping %d plays rejected by", n), err, "service", t.backend.Name())
+			}
+
+			if err := t.queue.drop(n); err != nil {
+				s.notifier.Error("updating scrobble queue of", err, "service", t.backend.Name())
+				break
+			}
+
+			plays = plays[n:]
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package scrobble
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mkozjak/blutui/internal/history"
+	"github.com/mkozjak/blutui/internal/notify"
+	"github.com/mkozjak/blutui/internal/player"
+)
+
+// standIn is a local ListenBrainz server that fails while down is set.
+type standIn struct {
+	mu          sync.Mutex
+	down        bool
+	submissions []lbSubmission
+}
+
+func (s *standIn) setDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/1/submit-listens" || r.Header.Get("Authorization") != "Token secret" {
+		http.Error(w, "bad request", http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.down {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+		return
+	}
+
+	var sub lbSubmission
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.submissions = append(s.submissions, sub)
+	w.Write([]byte(`{"status": "ok"}`))
+}
+
+func play(track string, at int64) history.Play {
+	return history.Play{Time: time.Unix(at, 0), Artist: "Low", Album: "Hey What", Track: track, Seconds: 300}
+}
+
+func TestListenBrainzOfflineQueue(t *testing.T) {
+	lb := &standIn{}
+	srv := httptest.NewServer(lb)
+	defer srv.Close()
+
+	s := New([]Backend{NewListenBrainz(srv.URL, "secret")}, t.TempDir(), notify.Log)
+
+	s.update(player.Status{State: "play", Artist: "Low", Album: "Hey What", Track: "White Horses"})
+	// the same track is announced only once
+	s.update(player.Status{State: "play", Artist: "Low", Album: "Hey What", Track: "White Horses", Secs: 10})
+	s.Scrobble(play("White Horses", 100))
+
+	lb.setDown(true)
+	s.Scrobble(play("I Can Wait", 400))
+	s.Scrobble(play("All Night", 700))
+
+	if q, _ := s.targets[0].queue.load(); len(q) != 2 {
+		t.Fatalf("got %d queued plays, want 2", len(q))
+	}
+
+	s.Retry()
+
+	lb.setDown(false)
+	s.Retry()
+
+	if q, _ := s.targets[0].queue.load(); len(q) != 0 {
+		t.Fatalf("got %d queued plays after retry, want 0", len(q))
+	}
+
+	want := []struct {
+		listenType string
+		tracks     []string
+	}{
+		{"playing_now", []string{"White Horses"}},
+		{"single", []string{"White Horses"}},
+		{"import", []string{"I Can Wait", "All Night"}},
+	}
+
+	if len(lb.submissions) != len(want) {
+		t.Fatalf("got %d submissions %+v, want %d", len(lb.submissions), lb.submissions, len(want))
+	}
+
+	for i, w := range want {
+		sub := lb.submissions[i]
+		if sub.ListenType != w.listenType || len(sub.Payload) != len(w.tracks) {
+			t.Fatalf("submission %d = %+v, want %s of %v", i, sub, w.listenType, w.tracks)
+		}
+
+		for j, tr := range w.tracks {
+			if sub.Payload[j].Track.Track != tr {
+				t.Errorf("submission %d listen %d = %q, want %q", i, j, sub.Payload[j].Track.Track, tr)
+			}
+		}
+	}
+
+	if at := lb.submissions[2].Payload[0].ListenedAt; at != 400 {
+		t.Errorf("listened_at = %d, want 400", at)
+	}
+}
+
+func TestListenBrainzRejected(t *testing.T) {
+	srv := httptest.NewServer(&standIn{})
+	defer srv.Close()
+
+	// an invalid token is not worth retrying
+	s := New([]Backend{NewListenBrainz(srv.URL, "invalid")}, t.TempDir(), notify.Log)
+	s.Scrobble(play("White Horses", 100))
+
+	if q, _ := s.targets[0].queue.load(); len(q) != 0 {
+		t.Fatalf("got %d queued plays, want 0", len(q))
+	}
+}
+
+func TestLastFM(t *testing.T) {
+	var got []url.Values
+	fail := true
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		got = append(got, r.PostForm)
+
+		if fail {
+			w.Write([]byte(`{"error": 16, "message": "temporarily unavailable"}`))
+			return
+		}
+
+		w.Write([]byte(`{"scrobbles": {}}`))
+	}))
+	defer srv.Close()
+
+	lf := NewLastFM(srv.URL, "key", "secret", "session")
+	s := New([]Backend{lf}, t.TempDir(), notify.Log)
+
+	s.Scrobble(play("White Horses", 100))
+
+	if q, _ := s.targets[0].queue.load(); len(q) != 1 {
+		t.Fatalf("got %d queued plays, want 1", len(q))
+	}
+
+	fail = false
+	s.Retry()
+
+	if len(got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got))
+	}
+
+	v := got[1]
+	if v.Get("method") != "track.scrobble" || v.Get("track[0]") != "White Horses" ||
+		v.Get("timestamp[0]") != "100" || v.Get("sk") != "session" {
+		t.Errorf("unexpected scrobble params %v", v)
+	}
+
+	sig := v.Get("api_sig")
+	v.Del("api_sig")
+	v.Del("format")
+
+	if want := lf.sign(v); sig != want {
+		t.Errorf("api_sig = %s, want %s", sig, want)
+	}
+
+	if q, _ := s.targets[0].queue.load(); len(q) != 0 {
+		t.Fatalf("got %d queued plays after retry, want 0", len(q))
+	}
+}
+
+func TestLastFMRateLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	// rate limited scrobbles are kept for a retry
+	s := New([]Backend{NewLastFM(srv.URL, "key", "secret", "session")}, t.TempDir(), notify.Log)
+	s.Scrobble(play("White Horses", 100))
+
+	if q, _ := s.targets[0].queue.load(); len(q) != 1 {
+		t.Fatalf("got %d queued plays, want 1", len(q))
+	}
+}
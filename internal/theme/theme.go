@@ -0,0 +1,406 @@
+// Package theme holds colours and glyphs of the user interface. A theme
+// is chosen by name and can be adjusted in a theme file.
+package theme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// File is the name of a file in the config directory that picks a theme
+// and overrides its colours and glyphs, e.g.
+//
+//	{
+//	  "base": "light",
+//	  "border": "navy",
+//	  "nowPlaying": "#d75f00",
+//	  "pages": {"radio": "olive"},
+//	  "spinner": "|/-\\"
+//	}
+//
+// Colours are W3C names, such as "cornflowerblue", "#rrggbb" hex values or
+// "default" for the terminal's colour.
+const File = "theme.json"
+
+// Theme holds colours and glyphs used across the user interface.
+type Theme struct {
+	Border       tcell.Color // borders of panes and popups
+	Text         tcell.Color
+	Dim          tcell.Color // secondary text, such as labels and counts
+	Accent       tcell.Color // artist on now playing, progress and history bars
+	SelectedText tcell.Color
+	Selected     tcell.Color // background of the selected item in a focused pane
+	BlurredText  tcell.Color
+	Blurred      tcell.Color // background of the selected item in other panes
+	NowPlaying   tcell.Color // currently playing artist and track
+	Warning      tcell.Color // warnings on the status bar and messages page
+	Error        tcell.Color // errors on the status bar and messages page
+
+	// Status bar label of the current page, with its background per page
+	PageText tcell.Color
+	Pages    map[string]tcell.Color
+
+	Spinner     string // frames of the loading indicator
+	SpinnerDone string
+	RepeatAll   string
+	RepeatOne   string
+	RepeatOff   string
+	ShuffleOn   string
+	ShuffleOff  string
+
+	// Mono is set if colours are disabled, in which case selections are
+	// shown in reverse video and the currently playing item underlined.
+	Mono bool
+}
+
+// Current is the theme in use. Like tview.Styles, it is set once at startup,
+// before any primitive is created.
+var Current = Dark()
+
+// Dark is the default theme, made for terminals with dark backgrounds.
+func Dark() *Theme {
+	t := glyphs()
+	t.Border = tcell.ColorCornflowerBlue
+	t.Text = tcell.ColorDefault
+	t.Dim = tcell.ColorGrey
+	t.Accent = tcell.ColorCornflowerBlue
+	t.SelectedText = tcell.ColorWhite
+	t.Selected = tcell.ColorCornflowerBlue
+	t.BlurredText = tcell.ColorWhite
+	t.Blurred = tcell.ColorLightGray
+	t.NowPlaying = tcell.ColorYellow
+	t.Warning = tcell.ColorOrange
+	t.Error = tcell.ColorRed
+	t.PageText = tcell.ColorWhite
+	t.Pages = map[string]tcell.Color{
+		"local":      tcell.ColorCornflowerBlue,
+		"tidal":      tcell.ColorGrey,
+		"playlists":  tcell.ColorDarkCyan,
+		"radio":      tcell.ColorDarkOrange,
+		"nowplaying": tcell.ColorSeaGreen,
+		"history":    tcell.ColorMediumPurple,
+		"messages":   tcell.ColorIndianRed,
+	}
+
+	return t
+}
+
+// Light is made for terminals with light backgrounds.
+func Light() *Theme {
+	t := glyphs()
+	t.Border = tcell.ColorRoyalBlue
+	t.Text = tcell.ColorDefault
+	t.Dim = tcell.ColorDimGray
+	t.Accent = tcell.ColorRoyalBlue
+	t.SelectedText = tcell.ColorWhite
+	t.Selected = tcell.ColorRoyalBlue
+	t.BlurredText = tcell.ColorBlack
+	t.Blurred = tcell.ColorSilver
+	t.NowPlaying = tcell.ColorOrangeRed
+	t.Warning = tcell.ColorDarkGoldenrod
+	t.Error = tcell.ColorCrimson
+	t.PageText = tcell.ColorWhite
+	t.Pages = map[string]tcell.Color{
+		"local":      tcell.ColorRoyalBlue,
+		"tidal":      tcell.ColorDimGray,
+		"playlists":  tcell.ColorDarkCyan,
+		"radio":      tcell.ColorChocolate,
+		"nowplaying": tcell.ColorSeaGreen,
+		"history":    tcell.ColorRebeccaPurple,
+		"messages":   tcell.ColorFireBrick,
+	}
+
+	return t
+}
+
+// Basic only uses the 16 standard colours, which follow the terminal's palette.
+func Basic() *Theme {
+	t := glyphs()
+	t.Border = tcell.ColorBlue
+	t.Text = tcell.ColorDefault
+	t.Dim = tcell.ColorGray
+	t.Accent = tcell.ColorBlue
+	t.SelectedText = tcell.ColorWhite
+	t.Selected = tcell.ColorBlue
+	t.BlurredText = tcell.ColorBlack
+	t.Blurred = tcell.ColorSilver
+	t.NowPlaying = tcell.ColorYellow
+	t.Warning = tcell.ColorYellow
+	t.Error = tcell.ColorRed
+	t.PageText = tcell.ColorWhite
+	t.Pages = map[string]tcell.Color{
+		"local":      tcell.ColorBlue,
+		"tidal":      tcell.ColorGray,
+		"playlists":  tcell.ColorTeal,
+		"radio":      tcell.ColorOlive,
+		"nowplaying": tcell.ColorGreen,
+		"history":    tcell.ColorPurple,
+		"messages":   tcell.ColorMaroon,
+	}
+
+	return t
+}
+
+// Mono uses the terminal's colours only, for NO_COLOR.
+func Mono() *Theme {
+	t := glyphs()
+	t.Border = tcell.ColorDefault
+	t.Text = tcell.ColorDefault
+	t.Dim = tcell.ColorDefault
+	t.Accent = tcell.ColorDefault
+	t.SelectedText = tcell.ColorDefault
+	t.Selected = tcell.ColorDefault
+	t.BlurredText = tcell.ColorDefault
+	t.Blurred = tcell.ColorDefault
+	t.NowPlaying = tcell.ColorDefault
+	t.Warning = tcell.ColorDefault
+	t.Error = tcell.ColorDefault
+	t.PageText = tcell.ColorDefault
+	t.Pages = map[string]tcell.Color{}
+	t.Mono = true
+
+	return t
+}
+
+// glyphs returns a theme holding default glyphs only.
+func glyphs() *Theme {
+	return &Theme{
+		Spinner:     "⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏",
+		SpinnerDone: "✓",
+		RepeatAll:   "♯",
+		RepeatOne:   "∞",
+		ShuffleOn:   "⤮",
+	}
+}
+
+// themes holds named themes.
+var themes = map[string]func() *Theme{
+	"dark":  Dark,
+	"light": Light,
+	"16":    Basic,
+}
+
+// Names returns names of available themes.
+func Names() []string {
+	var n []string
+	for name := range themes {
+		n = append(n, name)
+	}
+
+	sort.Strings(n)
+	return n
+}
+
+// Load returns the theme named name, or the one named in the [File] at path
+// if name is empty, adjusted by the file. A missing file means the dark
+// theme is used. If noColor is set, colours are disabled, but glyphs
+// still apply.
+func Load(path, name string, noColor bool) (*Theme, error) {
+	var f struct {
+		Base string `json:"base"`
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if data != nil {
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if name == "" {
+		name = f.Base
+	}
+
+	if name == "" {
+		name = "dark"
+	}
+
+	newTheme, ok := themes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q, available are %v", name, Names())
+	}
+
+	t := newTheme()
+
+	if data != nil {
+		if err := t.apply(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if noColor {
+		m := Mono()
+		m.Spinner, m.SpinnerDone = t.Spinner, t.SpinnerDone
+		m.RepeatAll, m.RepeatOne, m.RepeatOff = t.RepeatAll, t.RepeatOne, t.RepeatOff
+		m.ShuffleOn, m.ShuffleOff = t.ShuffleOn, t.ShuffleOff
+		t = m
+	}
+
+	return t, nil
+}
+
+// apply overrides colours and glyphs of t with those set in a theme file.
+func (t *Theme) apply(data []byte) error {
+	colors := map[string]*tcell.Color{
+		"border":       &t.Border,
+		"text":         &t.Text,
+		"dim":          &t.Dim,
+		"accent":       &t.Accent,
+		"selectedText": &t.SelectedText,
+		"selected":     &t.Selected,
+		"blurredText":  &t.BlurredText,
+		"blurred":      &t.Blurred,
+		"nowPlaying":   &t.NowPlaying,
+		"warning":      &t.Warning,
+		"error":        &t.Error,
+		"pageText":     &t.PageText,
+	}
+
+	glyphs := map[string]*string{
+		"spinner":     &t.Spinner,
+		"spinnerDone": &t.SpinnerDone,
+		"repeatAll":   &t.RepeatAll,
+		"repeatOne":   &t.RepeatOne,
+		"repeatOff":   &t.RepeatOff,
+		"shuffleOn":   &t.ShuffleOn,
+		"shuffleOff":  &t.ShuffleOff,
+	}
+
+	var f map[string]json.RawMessage
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	for k, v := range f {
+		var err error
+
+		switch {
+		case k == "base":
+		case k == "pages":
+			var pages map[string]string
+			if err = json.Unmarshal(v, &pages); err != nil {
+				break
+			}
+
+			for p, name := range pages {
+				var c tcell.Color
+				if c, err = parseColor(name); err != nil {
+					break
+				}
+
+				t.Pages[p] = c
+			}
+		case colors[k] != nil:
+			var name string
+			if err = json.Unmarshal(v, &name); err == nil {
+				*colors[k], err = parseColor(name)
+			}
+		case glyphs[k] != nil:
+			err = json.Unmarshal(v, glyphs[k])
+		default:
+			err = errors.New("unknown setting")
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+	}
+
+	if t.Spinner == "" {
+		return errors.New("spinner: no frames")
+	}
+
+	return nil
+}
+
+func parseColor(name string) (tcell.Color, error) {
+	if name == "default" {
+		return tcell.ColorDefault, nil
+	}
+
+	c := tcell.GetColor(name)
+	if c == tcell.ColorDefault {
+		return c, fmt.Errorf("invalid colour %q", name)
+	}
+
+	return c, nil
+}
+
+// Tag returns a style tag setting the foreground to c.
+func (t *Theme) Tag(c tcell.Color) string {
+	if t.Mono || c == tcell.ColorDefault {
+		return "[-]"
+	}
+
+	return "[" + c.String() + "]"
+}
+
+// NowPlayingTag returns a style tag that marks currently playing items.
+// Marks are removed by trimming it.
+func (t *Theme) NowPlayingTag() string {
+	if t.Mono {
+		return "[::u]"
+	}
+
+	return t.Tag(t.NowPlaying)
+}
+
+// SelectedStyle returns the style of the selected item of a pane,
+// depending on whether the pane has focus.
+func (t *Theme) SelectedStyle(focused bool) tcell.Style {
+	s := tcell.StyleDefault
+
+	switch {
+	case t.Mono && focused:
+		return s.Reverse(true)
+	case t.Mono:
+		return s.Underline(true)
+	case focused:
+		return s.Foreground(t.SelectedText).Background(t.Selected)
+	}
+
+	return s.Foreground(t.BlurredText).Background(t.Blurred)
+}
+
+// PageColors returns the text and background colour of the status bar
+// label of a page.
+func (t *Theme) PageColors(page string) (tcell.Color, tcell.Color) {
+	bg, ok := t.Pages[page]
+	if !ok {
+		return t.Text, tcell.ColorDefault
+	}
+
+	return t.PageText, bg
+}
+
+// RepeatGlyph returns the glyph of a repeat mode, 0 repeating the queue,
+// 1 a track and 2 being off.
+func (t *Theme) RepeatGlyph(mode int) string {
+	switch mode {
+	case 0:
+		return t.RepeatAll
+	case 1:
+		return t.RepeatOne
+	}
+
+	return t.RepeatOff
+}
+
+// ShuffleGlyph returns the glyph of a shuffle mode.
+func (t *Theme) ShuffleGlyph(on bool) string {
+	if on {
+		return t.ShuffleOn
+	}
+
+	return t.ShuffleOff
+}
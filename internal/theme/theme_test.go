@@ -0,0 +1,138 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func writeTheme(t *testing.T, data string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), File)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadDefault(t *testing.T) {
+	th, err := Load(filepath.Join(t.TempDir(), File), "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if th.Border != tcell.ColorCornflowerBlue || th.RepeatAll != "♯" || th.Mono {
+		t.Errorf("missing file should give the dark theme, got %+v", th)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), File), "solarized", false); err == nil {
+		t.Error("unknown theme name should fail")
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	path := writeTheme(t, `{
+		"base": "light",
+		"border": "navy",
+		"nowPlaying": "#d75f00",
+		"pages": {"radio": "olive"},
+		"spinner": "|/-\\",
+		"repeatOff": "-"
+	}`)
+
+	th, err := Load(path, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if th.Border != tcell.ColorNavy {
+		t.Errorf("border = %v, want navy", th.Border)
+	}
+
+	if th.NowPlaying != tcell.NewHexColor(0xd75f00) {
+		t.Errorf("nowPlaying = %v, want #d75f00", th.NowPlaying)
+	}
+
+	if fg, bg := th.PageColors("radio"); fg != th.PageText || bg != tcell.ColorOlive {
+		t.Errorf("radio page colours = %v, %v", fg, bg)
+	}
+
+	// light is kept for colours that are not overridden
+	if th.Selected != tcell.ColorRoyalBlue {
+		t.Errorf("selected = %v, want the light theme's", th.Selected)
+	}
+
+	if th.Spinner != `|/-\` || th.RepeatGlyph(2) != "-" || th.RepeatGlyph(1) != "∞" {
+		t.Errorf("glyphs not applied: %+v", th)
+	}
+
+	// the flag takes precedence over the file's base
+	th, err = Load(path, "16", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if th.Selected != tcell.ColorBlue || th.Border != tcell.ColorNavy {
+		t.Errorf("16 theme with overrides = %+v", th)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"border": "notacolour"}`,
+		`{"pages": {"local": "nope"}}`,
+		`{"brder": "navy"}`,
+		`{"spinner": ""}`,
+		`{"border": 1}`,
+		`not json`,
+	} {
+		if _, err := Load(writeTheme(t, data), "", false); err == nil {
+			t.Errorf("Load(%s) should fail", data)
+		}
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	th, err := Load(writeTheme(t, `{"border": "navy", "shuffleOn": "S"}`), "light", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !th.Mono || th.Border != tcell.ColorDefault {
+		t.Errorf("NO_COLOR should disable colours, got %+v", th)
+	}
+
+	if th.ShuffleGlyph(true) != "S" {
+		t.Errorf("NO_COLOR should keep glyphs, got %q", th.ShuffleOn)
+	}
+
+	if th.Tag(tcell.ColorRed) != "[-]" || th.NowPlayingTag() != "[::u]" {
+		t.Errorf("mono tags = %q, %q", th.Tag(tcell.ColorRed), th.NowPlayingTag())
+	}
+
+	if _, _, attr := th.SelectedStyle(true).Decompose(); attr&tcell.AttrReverse == 0 {
+		t.Error("focused selection should be reversed")
+	}
+}
+
+func TestTag(t *testing.T) {
+	th := Dark()
+
+	// some colours have two names, such as gray and grey, either of which
+	// tcell may return, so use one with a single name
+	if got := th.Tag(tcell.ColorCornflowerBlue); got != "[cornflowerblue]" {
+		t.Errorf("Tag(cornflowerblue) = %q, want [cornflowerblue]", got)
+	}
+
+	if got := th.Tag(tcell.ColorDefault); got != "[-]" {
+		t.Errorf("Tag(default) = %q, want [-]", got)
+	}
+
+	if got := th.NowPlayingTag(); got != "[yellow]" {
+		t.Errorf("NowPlayingTag() = %q, want [yellow]", got)
+	}
+}
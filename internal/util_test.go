@@ -1,7 +1,6 @@
 package internal
 
 import (
-	"fmt"
 	"reflect"
 	"testing"
 )
@@ -47,26 +46,6 @@ func TestCapitalizeArtist(t *testing.T) {
 	}
 }
 
-func TestCaser(t *testing.T) {
-	type test struct {
-		s    string
-		want string
-	}
-
-	tests := []test{
-		{s: "koRn", want: "Korn"},
-		{s: "croatia", want: "Croatia"},
-		{s: "mercedes", want: "Mercedes"},
-	}
-
-	for _, tc := range tests {
-		got := Caser(tc.s)
-		if !reflect.DeepEqual(tc.want, got) {
-			t.Fatalf("expected: %v, got: %v", tc.want, got)
-		}
-	}
-}
-
 func TestExtractAlbumYear(t *testing.T) {
 	type ok struct {
 		y    string
@@ -105,46 +84,24 @@ func TestExtractAlbumYear(t *testing.T) {
 
 }
 
-func TestHackAlbumYear(t *testing.T) {
-	type ok struct {
-		url  string
+func TestExtractYearFromPath(t *testing.T) {
+	type test struct {
+		path string
 		want int
 	}
 
-	type nok struct {
-		url  string
-		want string
-	}
-
-	oks := []ok{
-		{url: "LocalMusic:contextMenu/Song?filename=%2Fvar%2Fmnt%2FHOME-music%2F" +
-			"Kamelot%2F%5B2003%5D%20Epica%2F01%20-%20Prologue.flac", want: 2003},
-		{url: "LocalMusic:contextMenu/Song?filename=%2Fvar%2Fmnt%2FHOME-music%2F" +
-			"Bohren%20and%20Der%20Club%20of%20Gore%2F%5B2002%5D%20Black%20Earth%2F01%20-" +
-			"%20Midnight%20Black%20Earth.flac", want: 2002},
-		{url: "LocalMusic:contextMenu/Song?filename=%2Fvar%2Fmnt%2FHOME-music%2F" +
-			"Rage%20Against%20The%20Machine%2F%5B2000%5D%20Renegades%2F01.%20Microphone%20Fiend.flac", want: 2000},
-	}
-
-	for _, tc := range oks {
-		got, _ := HackAlbumYear(tc.url)
-		if !reflect.DeepEqual(tc.want, got) {
-			t.Fatalf("expected: %v, got: %v", tc.want, got)
-		}
-	}
-
-	fails := []nok{
-		{url: "LocalMusic:contextMenu/Song?filename=%2Fvar%2Fmnt%2FHOME-music%2F" +
-			"_acoustic%2FJay%20Smith%20-%20Let%20My%20Heart%20Go.mp3", want: "year could not be found"},
+	tests := []test{
+		{path: "/var/mnt/music/Kamelot/[2003] Epica/01 - Prologue.flac", want: 2003},
+		{path: "/var/mnt/music/Low/Secret Name (1999)/01 Starfire.flac", want: 1999},
+		{path: "/var/mnt/music/Low/Secret Name/01 Starfire.flac", want: 0},
 	}
 
-	for _, tc := range fails {
-		_, err := HackAlbumYear(tc.url)
-		if !reflect.DeepEqual(err.Error(), tc.want) {
-			t.Errorf("expected: %v, got: %v", tc.want, err.Error())
+	for _, tc := range tests {
+		got, _ := ExtractYearFromPath(tc.path)
+		if got != tc.want {
+			t.Errorf("%s: expected: %v, got: %v", tc.path, tc.want, got)
 		}
 	}
-
 }
 
 func TestEscapeStyleTag(t *testing.T) {
@@ -167,91 +124,64 @@ func TestEscapeStyleTag(t *testing.T) {
 	}
 }
 
-func TestCleanTrackName(t *testing.T) {
+func TestSplitTrackNumber(t *testing.T) {
 	type test struct {
-		s    string
-		want string
+		s      string
+		number int
+		want   string
 	}
 
 	tests := []test{
-		{s: "01. What Is This", want: "What Is This"},
+		{s: "01. What Is This", number: 1, want: "What Is This"},
+		{s: "12. 1979", number: 12, want: "1979"},
 		{s: "Make Me Bad", want: "Make Me Bad"},
 		{s: "mercedes", want: "mercedes"},
+		{s: "4.50 from Paddington", want: "4.50 from Paddington"},
 	}
 
 	for _, tc := range tests {
-		got := CleanTrackName(tc.s)
-		if !reflect.DeepEqual(tc.want, got) {
-			t.Fatalf("expected: %v, got: %v", tc.want, got)
+		number, got := SplitTrackNumber(tc.s)
+		if number != tc.number || got != tc.want {
+			t.Fatalf("expected: %d %v, got: %d %v", tc.number, tc.want, number, got)
 		}
 	}
 }
 
-func TestCleanAlbumName(t *testing.T) {
+func TestDiscFromPath(t *testing.T) {
 	type test struct {
 		s    string
-		want string
+		want int
 	}
 
 	tests := []test{
-		{s: "[::b]It Was Good Until It Wasn't [clean[] (2020)", want: "It Was Good Until It Wasn't [clean]"},
+		{s: "/var/mnt/music/Low/Box Set/CD2/01 Intro.flac", want: 2},
+		{s: "/music/Low/Box Set/Disc 3/01 Intro.flac", want: 3},
+		{s: "/music/Low/Box Set/2-01 Intro.flac", want: 2},
+		{s: "/music/Low/Secret Name/01 Starfire.flac", want: 0},
+		{s: "/music/CDs/Low/01 Starfire.flac", want: 0},
 	}
 
 	for _, tc := range tests {
-		got := CleanAlbumName(tc.s)
-		if !reflect.DeepEqual(tc.want, got) {
-			t.Fatalf("expected: %v, got: %v", tc.want, got)
+		if got := DiscFromPath(tc.s); got != tc.want {
+			t.Fatalf("%s: expected: %v, got: %v", tc.s, tc.want, got)
 		}
 	}
 }
 
-func TestJWSimilarity(t *testing.T) {
+func TestCleanAlbumName(t *testing.T) {
 	type test struct {
-		s1   string
-		s2   string
-		want float64
+		s    string
+		want string
 	}
 
 	tests := []test{
-		{s1: "faremviel", s2: "farmville", want: 0.91898},
-		{s1: "korn", s2: "cynic", want: 0},
-		{s1: "limp bizkit", s2: "limp bizkit", want: 1},
-		{s1: "drmea theter", s2: "dream theater", want: 0.9453},
-		{s1: "inter", s2: "In", want: 0.84},
-		{s1: "", s2: "In", want: 0},
-		{s1: "", s2: "", want: 0},
+		{s: "[::b]It Was Good Until It Wasn't [clean[] (2020)", want: "It Was Good Until It Wasn't [clean]"},
 	}
 
 	for _, tc := range tests {
-		got := JWSimilarity(tc.s1, tc.s2)
-		fmt.Printf("Test case: %s vs %s -> Result: %.5f\n", tc.s1, tc.s2, got)
-
+		got := CleanAlbumName(tc.s)
 		if !reflect.DeepEqual(tc.want, got) {
 			t.Fatalf("expected: %v, got: %v", tc.want, got)
 		}
 	}
 }
-
-var result float64
-
-func BenchmarkJWSimilarity(b *testing.B) {
-	type benchmark struct {
-		name string
-		s1   string
-		s2   string
-	}
-
-	benchmarks := []benchmark{
-		{s1: "faremviel", s2: "farmville"},
-		{s1: "foo", s2: "bar"},
-		{s1: "london", s2: "london"},
-	}
-
-	for _, bm := range benchmarks {
-		b.Run(bm.name, func(b *testing.B) {
-			for i := 0; i < b.N; i++ {
-				JWSimilarity(bm.s1, bm.s2)
-			}
-		})
-	}
-}
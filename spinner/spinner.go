@@ -26,6 +26,7 @@ type Spinner struct {
 	currentStyle SpinnerStyle
 	styles       map[SpinnerStyle][]rune
 	active       bool
+	done         string
 	stop         chan bool
 	draw         func() *tview.Application
 }
@@ -74,6 +75,7 @@ func New(d func() *tview.Application) *Spinner {
 			SpinnerBoxBounce:      []rune(`▌▀▐▄`),
 		},
 		active: false,
+		done:   "✓",
 		draw:   d,
 		stop:   make(chan bool),
 	}
@@ -93,7 +95,7 @@ func (s *Spinner) Draw(screen tcell.Screen) {
 	} else {
 		s.Box.DrawForSubclass(screen, tview.NewTextView())
 		x, y, width, _ := s.Box.GetInnerRect()
-		tview.Print(screen, "✓", x, y, width, tview.AlignLeft, tcell.ColorDefault)
+		tview.Print(screen, s.done, x, y, width, tview.AlignLeft, tcell.ColorDefault)
 	}
 }
 
@@ -150,3 +152,10 @@ func (s *Spinner) SetCustomStyle(frames []rune) *Spinner {
 
 	return s
 }
+
+// SetDone sets a glyph shown while the spinner is stopped.
+func (s *Spinner) SetDone(glyph string) *Spinner {
+	s.done = glyph
+
+	return s
+}